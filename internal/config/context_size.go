@@ -0,0 +1,54 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// contextWindows lists the context window in tokens of known models, matched
+// by the longest prefix of the model name.
+var contextWindows = map[string]int{
+	"gpt-5":             400000,
+	"gpt-4.1":           1047576,
+	"gpt-4o":            128000,
+	"gpt-4-turbo":       128000,
+	"gpt-4":             8192,
+	"gpt-3.5-turbo":     16385,
+	"o1":                200000,
+	"o1-mini":           128000,
+	"o3":                200000,
+	"o4-mini":           200000,
+	"gemini-2.5":        1048576,
+	"gemini-2.0":        1048576,
+	"gemini-1.5-pro":    2097152,
+	"gemini-1.5-flash":  1048576,
+	"deepseek-chat":     65536,
+	"deepseek-reasoner": 65536,
+}
+
+// ContextWindow returns the context window in tokens of a model, falling
+// back to the engine's default for unknown models. Ollama models always use
+// the engine default, since Ollama truncates every prompt to its num_ctx.
+func ContextWindow(engineName string, model string) int {
+	engine, exists := CheckAndGetEngine(engineName)
+
+	if !exists || engine.Name != OllamaEngine {
+		keys := make([]string, 0, len(contextWindows))
+		for key := range contextWindows {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return len(keys[i]) > len(keys[j]) })
+
+		model = strings.ToLower(model)
+		for _, key := range keys {
+			if strings.HasPrefix(model, key) {
+				return contextWindows[key]
+			}
+		}
+	}
+
+	if exists && engine.ContextWindow > 0 {
+		return engine.ContextWindow
+	}
+	return 8192
+}
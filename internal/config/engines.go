@@ -11,6 +11,10 @@ const (
 	GPTEngine      = "GPT"
 	DeepSeekEngine = "DeepSeek"
 	OllamaEngine   = "Ollama"
+
+	// OpenAICompatibleEngine talks to any server exposing the OpenAI
+	// chat-completions API, such as vLLM, LM Studio or llama.cpp server.
+	OpenAICompatibleEngine = "OpenAICompatible"
 )
 
 // EngineMap stores all available engines
@@ -27,7 +31,8 @@ var EngineMap = map[string]structs.Engine{
 			"gpt-4o-mini",
 			"gpt-4o-mini-2024-07-18",
 		},
-		DefaultModel: "gpt-4",
+		DefaultModel:  "gpt-4",
+		ContextWindow: 8192,
 		Features: structs.EngineFeatures{
 			SupportsImageGen:      true,
 			SupportsChat:          true,
@@ -45,7 +50,8 @@ var EngineMap = map[string]structs.Engine{
 			"gemini-2.0-flash",
 			"gemini-2.0-flash-lite",
 		},
-		DefaultModel: "gemini-2.5-flash",
+		DefaultModel:  "gemini-2.5-flash",
+		ContextWindow: 1048576,
 		Features: structs.EngineFeatures{
 			SupportsImageGen:      false,
 			SupportsChat:          true,
@@ -60,7 +66,8 @@ var EngineMap = map[string]structs.Engine{
 			"deepseek-chat",
 			"deepseek-reasoner",
 		},
-		DefaultModel: "deepseek-chat",
+		DefaultModel:  "deepseek-chat",
+		ContextWindow: 65536,
 		Features: structs.EngineFeatures{
 			SupportsImageGen:      false,
 			SupportsChat:          true,
@@ -74,7 +81,22 @@ var EngineMap = map[string]structs.Engine{
 		Models: []string{
 			"llama3.2",
 		},
-		DefaultModel: "llama3.2",
+		DefaultModel:  "llama3.2",
+		ContextWindow: 4096,
+		Features: structs.EngineFeatures{
+			SupportsImageGen:      false,
+			SupportsChat:          true,
+			SupportsSafeMode:      false,
+			SupportsReasoning:     false,
+			SupportsDocumentation: true,
+		},
+	},
+	"OpenAICompatible": {
+		Name: "OpenAICompatible",
+		// Models are configured by the user, see `genie init`
+		Models:        []string{},
+		DefaultModel:  "",
+		ContextWindow: 8192,
 		Features: structs.EngineFeatures{
 			SupportsImageGen:      false,
 			SupportsChat:          true,
@@ -105,19 +127,29 @@ func GetNextEngine(currentEngine string) string {
 	case "DeepSeek":
 		return "Ollama"
 	case "Ollama":
+		return "OpenAICompatible"
+	case "OpenAICompatible":
 		return "Gemini"
 	default:
 		return "Gemini"
 	}
 }
 
+// IsValidModel reports whether modelName is served by the engine. Models
+// discovered from the provider take precedence; the static list in EngineMap
+// is only consulted when nothing has been discovered yet.
 func IsValidModel(engineName string, modelName string) bool {
 	engine, exists := EngineMap[engineName]
 	if !exists {
 		return false
 	}
 
-	for _, model := range engine.Models {
+	models := engine.Models
+	if cached, ok := GetCachedModels(engine.Name); ok {
+		models = cached.Models
+	}
+
+	for _, model := range models {
 		if model == modelName {
 			return true
 		}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseEngineChain parses a fallback chain such as "Gemini -> GPT -> Ollama"
+// or "Gemini,GPT,Ollama" into canonical engine names.
+func ParseEngineChain(chain string) ([]string, error) {
+	chain = strings.ReplaceAll(chain, "->", ",")
+
+	var engines []string
+	seen := map[string]bool{}
+	for _, name := range strings.Split(chain, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		engine, exists := CheckAndGetEngine(name)
+		if !exists {
+			return nil, fmt.Errorf("unknown engine name: %s", name)
+		}
+		if !seen[engine.Name] {
+			seen[engine.Name] = true
+			engines = append(engines, engine.Name)
+		}
+	}
+
+	if len(engines) == 0 {
+		return nil, fmt.Errorf("the fallback chain is empty")
+	}
+	return engines, nil
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseEngineChain(t *testing.T) {
+	tests := []struct {
+		chain string
+		want  []string
+	}{
+		{"Gemini -> GPT -> Ollama", []string{GeminiEngine, GPTEngine, OllamaEngine}},
+		{"Gemini,GPT,Ollama", []string{GeminiEngine, GPTEngine, OllamaEngine}},
+		{"gpt->deepseek", []string{GPTEngine, DeepSeekEngine}},
+		{" GPT , GPT -> Gemini ", []string{GPTEngine, GeminiEngine}},
+		{"Ollama ->", []string{OllamaEngine}},
+	}
+	for _, test := range tests {
+		got, err := ParseEngineChain(test.chain)
+		if err != nil {
+			t.Errorf("ParseEngineChain(%q): %v", test.chain, err)
+			continue
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("ParseEngineChain(%q) = %v, want %v", test.chain, got, test.want)
+		}
+	}
+
+	for _, chain := range []string{"", " -> ", "Gemini -> Claude"} {
+		if got, err := ParseEngineChain(chain); err == nil {
+			t.Errorf("ParseEngineChain(%q) = %v, want an error", chain, got)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ModelCacheTTL is how long discovered model lists are trusted before genie
+// asks the provider again.
+const ModelCacheTTL = 24 * time.Hour
+
+// CachedModels is the model list discovered for a single engine.
+type CachedModels struct {
+	Models    []string  `json:"models"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// Fresh reports whether the cached list is younger than ModelCacheTTL.
+func (c CachedModels) Fresh() bool {
+	return time.Since(c.FetchedAt) < ModelCacheTTL
+}
+
+func getModelCachePath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "models_cache.json"), nil
+}
+
+func loadModelCache() (map[string]CachedModels, error) {
+	cache := map[string]CachedModels{}
+
+	path, err := getModelCachePath()
+	if err != nil {
+		return cache, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return cache, err
+	}
+
+	if err := json.Unmarshal(data, &cache); err != nil {
+		// A corrupt cache is simply rebuilt on the next discovery
+		return map[string]CachedModels{}, nil
+	}
+	return cache, nil
+}
+
+// GetCachedModels returns the cached model list for an engine, if any.
+func GetCachedModels(engineName string) (CachedModels, bool) {
+	cache, err := loadModelCache()
+	if err != nil {
+		return CachedModels{}, false
+	}
+	cached, ok := cache[engineName]
+	return cached, ok && len(cached.Models) > 0
+}
+
+// SaveCachedModels stores the discovered model list for an engine.
+func SaveCachedModels(engineName string, models []string) error {
+	cache, _ := loadModelCache()
+	cache[engineName] = CachedModels{
+		Models:    models,
+		FetchedAt: time.Now(),
+	}
+
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path, err := getModelCachePath()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
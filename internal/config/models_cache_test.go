@@ -0,0 +1,71 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestModelCache(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	if _, ok := GetCachedModels(GeminiEngine); ok {
+		t.Fatal("found cached models without a cache")
+	}
+
+	if err := SaveCachedModels(GeminiEngine, []string{"gemini-a", "gemini-b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveCachedModels(OllamaEngine, []string{"llama3"}); err != nil {
+		t.Fatal(err)
+	}
+	cached, ok := GetCachedModels(GeminiEngine)
+	if !ok || !slices.Equal(cached.Models, []string{"gemini-a", "gemini-b"}) || !cached.Fresh() {
+		t.Errorf("cached Gemini models are %+v, %v, want the saved fresh list", cached, ok)
+	}
+	if cached, ok := GetCachedModels(OllamaEngine); !ok || !slices.Equal(cached.Models, []string{"llama3"}) {
+		t.Errorf("cached Ollama models are %+v, %v, want the saved list next to Gemini's", cached, ok)
+	}
+
+	if err := SaveCachedModels(GPTEngine, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := GetCachedModels(GPTEngine); ok {
+		t.Error("an empty model list counts as cached")
+	}
+
+	// A corrupt cache is treated as empty and rebuilt
+	path := filepath.Join(home, ".genie", "models_cache.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := GetCachedModels(GeminiEngine); ok {
+		t.Error("found cached models in a corrupt cache")
+	}
+	if err := SaveCachedModels(GeminiEngine, []string{"gemini-c"}); err != nil {
+		t.Fatal(err)
+	}
+	if cached, ok := GetCachedModels(GeminiEngine); !ok || !slices.Equal(cached.Models, []string{"gemini-c"}) {
+		t.Errorf("cached models after a corrupt cache are %+v, %v, want the saved list", cached, ok)
+	}
+}
+
+func TestCachedModelsFresh(t *testing.T) {
+	tests := []struct {
+		age  time.Duration
+		want bool
+	}{
+		{0, true},
+		{ModelCacheTTL - time.Minute, true},
+		{ModelCacheTTL + time.Minute, false},
+	}
+	for _, test := range tests {
+		cached := CachedModels{Models: []string{"m"}, FetchedAt: time.Now().Add(-test.age)}
+		if got := cached.Fresh(); got != test.want {
+			t.Errorf("a list fetched %s ago is fresh: %v, want %v", test.age, got, test.want)
+		}
+	}
+}
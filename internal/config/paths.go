@@ -0,0 +1,19 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// GetConfigDir returns genie's config directory (~/.genie), creating it if needed.
+func GetConfigDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	configDir := filepath.Join(homeDir, ".genie")
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		return "", err
+	}
+	return configDir, nil
+}
@@ -0,0 +1,238 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// ProfileEnvVar selects the active profile for a single invocation.
+const ProfileEnvVar = "GENIE_PROFILE"
+
+// Profile is a named bundle of settings that replaces the stored engine,
+// model, ignore list and Ollama URL while it is active. API keys are not kept
+// in the profile itself: Keys maps a key name such as openai_api_key to the
+// secret store entry that holds the profile's value.
+type Profile struct {
+	Name           string            `json:"name"`
+	Engine         string            `json:"engine,omitempty"`
+	Model          string            `json:"model,omitempty"`
+	Keys           map[string]string `json:"keys,omitempty"`
+	IgnoreListPath string            `json:"ignore_list_path,omitempty"`
+	OllamaURL      string            `json:"ollama_url,omitempty"`
+	SafeMode       bool              `json:"safe_mode,omitempty"`
+	Temperature    float32           `json:"temperature,omitempty"`
+}
+
+// Setting returns the value the profile sets for a stored setting key, such
+// as engineName or ollama_url.
+func (p Profile) Setting(key string) (string, bool) {
+	var value string
+	switch key {
+	case "engineName":
+		value = p.Engine
+	case "modelName":
+		value = p.Model
+	case "ignore_list_path":
+		value = p.IgnoreListPath
+	case "ollama_url":
+		value = p.OllamaURL
+	}
+	return value, value != ""
+}
+
+// SetSetting changes a stored setting of the profile. It reports false for
+// keys that profiles do not carry.
+func (p *Profile) SetSetting(key, value string) bool {
+	switch key {
+	case "engineName":
+		p.Engine = value
+	case "modelName":
+		p.Model = value
+	case "ignore_list_path":
+		p.IgnoreListPath = value
+	case "ollama_url":
+		p.OllamaURL = value
+	default:
+		return false
+	}
+	return true
+}
+
+// ProfileKeyEntry returns the secret store entry for a profile's API key.
+func ProfileKeyEntry(profile, key string) string {
+	return "profile." + profile + "." + key
+}
+
+type profileFile struct {
+	Active   string             `json:"active,omitempty"`
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+var (
+	profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+	profileOverride    string
+)
+
+// SetProfileOverride makes name the active profile for this process, as the
+// --profile flag does. An empty name keeps the stored selection.
+func SetProfileOverride(name string) {
+	profileOverride = name
+}
+
+// ValidateProfileName checks that a profile name is usable as a key.
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
+
+func getProfilesPath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "profiles.json"), nil
+}
+
+func loadProfiles() (profileFile, error) {
+	file := profileFile{Profiles: map[string]Profile{}}
+
+	path, err := getProfilesPath()
+	if err != nil {
+		return file, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return file, nil
+	}
+	if err != nil {
+		return file, err
+	}
+
+	if err := json.Unmarshal(data, &file); err != nil {
+		return file, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if file.Profiles == nil {
+		file.Profiles = map[string]Profile{}
+	}
+	return file, nil
+}
+
+func saveProfiles(file profileFile) error {
+	path, err := getProfilesPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// ListProfiles returns all profiles sorted by name.
+func ListProfiles() ([]Profile, error) {
+	file, err := loadProfiles()
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := make([]Profile, 0, len(file.Profiles))
+	for _, profile := range file.Profiles {
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Name < profiles[j].Name
+	})
+	return profiles, nil
+}
+
+// GetProfile returns the profile with the given name.
+func GetProfile(name string) (Profile, bool, error) {
+	file, err := loadProfiles()
+	if err != nil {
+		return Profile{}, false, err
+	}
+	profile, ok := file.Profiles[name]
+	return profile, ok, nil
+}
+
+// SaveProfile creates or replaces a profile.
+func SaveProfile(profile Profile) error {
+	if err := ValidateProfileName(profile.Name); err != nil {
+		return err
+	}
+
+	file, err := loadProfiles()
+	if err != nil {
+		return err
+	}
+	file.Profiles[profile.Name] = profile
+	return saveProfiles(file)
+}
+
+// DeleteProfile removes a profile and deactivates it if it was active.
+func DeleteProfile(name string) error {
+	file, err := loadProfiles()
+	if err != nil {
+		return err
+	}
+	if _, ok := file.Profiles[name]; !ok {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+
+	delete(file.Profiles, name)
+	if file.Active == name {
+		file.Active = ""
+	}
+	return saveProfiles(file)
+}
+
+// SetActiveProfile stores the profile used by later commands. An empty name
+// goes back to the settings outside any profile.
+func SetActiveProfile(name string) error {
+	file, err := loadProfiles()
+	if err != nil {
+		return err
+	}
+	if _, ok := file.Profiles[name]; name != "" && !ok {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+
+	file.Active = name
+	return saveProfiles(file)
+}
+
+// ActiveProfile returns the profile in effect: the --profile flag, then
+// GENIE_PROFILE, then the stored selection. It reports false when no profile
+// is active.
+func ActiveProfile() (Profile, bool, error) {
+	file, err := loadProfiles()
+	if err != nil {
+		return Profile{}, false, err
+	}
+
+	name := file.Active
+	if env := os.Getenv(ProfileEnvVar); env != "" {
+		name = env
+	}
+	if profileOverride != "" {
+		name = profileOverride
+	}
+	if name == "" {
+		return Profile{}, false, nil
+	}
+
+	profile, ok := file.Profiles[name]
+	if !ok {
+		return Profile{}, false, fmt.Errorf("profile %q does not exist", name)
+	}
+	return profile, true, nil
+}
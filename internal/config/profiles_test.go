@@ -0,0 +1,97 @@
+package config
+
+import "testing"
+
+func TestActiveProfilePrecedence(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(ProfileEnvVar, "")
+	t.Cleanup(func() { SetProfileOverride("") })
+	for _, name := range []string{"stored", "env", "flag"} {
+		if err := SaveProfile(Profile{Name: name, Engine: GPTEngine}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, active, err := ActiveProfile(); err != nil || active {
+		t.Errorf("ActiveProfile without a selection = %v, %v, want no profile", active, err)
+	}
+	if err := SetActiveProfile("stored"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		env, flag string
+		want      string
+	}{
+		{"", "", "stored"},
+		{"env", "", "env"},
+		{"env", "flag", "flag"},
+		{"", "flag", "flag"},
+	}
+	for _, test := range tests {
+		t.Setenv(ProfileEnvVar, test.env)
+		SetProfileOverride(test.flag)
+		profile, active, err := ActiveProfile()
+		if err != nil || !active || profile.Name != test.want {
+			t.Errorf("ActiveProfile with GENIE_PROFILE=%q and --profile=%q = %s, %v, %v, want %s", test.env, test.flag, profile.Name, active, err, test.want)
+		}
+	}
+	SetProfileOverride("")
+
+	t.Setenv(ProfileEnvVar, "missing")
+	if _, _, err := ActiveProfile(); err == nil {
+		t.Error("ActiveProfile with an unknown GENIE_PROFILE succeeded, want an error")
+	}
+	t.Setenv(ProfileEnvVar, "")
+
+	// Deleting the active profile goes back to the global settings
+	if err := DeleteProfile("stored"); err != nil {
+		t.Fatal(err)
+	}
+	if _, active, err := ActiveProfile(); err != nil || active {
+		t.Errorf("ActiveProfile after deleting it = %v, %v, want no profile", active, err)
+	}
+	if err := SetActiveProfile("stored"); err == nil {
+		t.Error("SetActiveProfile of a deleted profile succeeded, want an error")
+	}
+}
+
+func TestProfileSettings(t *testing.T) {
+	profile := Profile{Name: "work"}
+	for key, value := range map[string]string{
+		"engineName":       GPTEngine,
+		"modelName":        "gpt-4o",
+		"ignore_list_path": "/home/me/.genieignore",
+		"ollama_url":       "http://gpu:11434",
+	} {
+		if !profile.SetSetting(key, value) {
+			t.Errorf("SetSetting(%s) was refused", key)
+		}
+		if got, ok := profile.Setting(key); !ok || got != value {
+			t.Errorf("Setting(%s) = %q, %v, want %q", key, got, ok, value)
+		}
+	}
+	if profile.SetSetting("openai_api_key", "sk-test") {
+		t.Error("SetSetting accepted an API key, which belongs in the secret store")
+	}
+	if _, ok := (Profile{}).Setting("engineName"); ok {
+		t.Error("an empty profile sets the engine, want it left to the global settings")
+	}
+}
+
+func TestProfileKeyEntry(t *testing.T) {
+	if got := ProfileKeyEntry("work", "openai_api_key"); got != "profile.work.openai_api_key" {
+		t.Errorf("ProfileKeyEntry = %s, want profile.work.openai_api_key", got)
+	}
+	// Names that could make two profiles share an entry are not allowed
+	for _, name := range []string{"work", "team-a", "ci_2"} {
+		if err := ValidateProfileName(name); err != nil {
+			t.Errorf("ValidateProfileName(%q) = %v, want it accepted", name, err)
+		}
+	}
+	for _, name := range []string{"", "a.b", "-x", "my work", "../x"} {
+		if err := ValidateProfileName(name); err == nil {
+			t.Errorf("ValidateProfileName(%q) succeeded, want an error", name)
+		}
+	}
+}
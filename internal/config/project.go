@@ -0,0 +1,141 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFileName is the per-project configuration genie looks for in the
+// working directory and its parents.
+const ProjectFileName = ".genie.yaml"
+
+// Project is the configuration of the repository genie runs in. Its values
+// override the global settings and the active profile.
+type Project struct {
+	// Path is the .genie.yaml the configuration was read from.
+	Path string `yaml:"-"`
+
+	Engine string `yaml:"engine"`
+	Model  string `yaml:"model"`
+	// Ignore lists patterns skipped in addition to the ignore list file.
+	Ignore []string `yaml:"ignore"`
+	// PromptAdditions is appended to the prompts of the command it is keyed
+	// by, or of every command for the key "all".
+	PromptAdditions map[string]string `yaml:"prompt_additions"`
+	ReadmeTemplate  string            `yaml:"readme_template"`
+	// BugOutputDir is where genie bug report writes, relative to the project.
+	BugOutputDir string `yaml:"bug_output_dir"`
+	SafeMode     *bool  `yaml:"safe_mode"`
+	// CommentMarkers adds file extensions to genie summarize, e.g. ".vue": "//".
+	CommentMarkers map[string]string `yaml:"comment_markers"`
+}
+
+// Dir returns the directory of the project, the one holding .genie.yaml.
+func (p *Project) Dir() string {
+	return filepath.Dir(p.Path)
+}
+
+// Setting returns the value the project sets for a stored setting key.
+func (p *Project) Setting(key string) (string, bool) {
+	var value string
+	switch key {
+	case "engineName":
+		value = p.Engine
+	case "modelName":
+		value = p.Model
+	}
+	return value, value != ""
+}
+
+// PromptAddition returns the extra instructions for command's prompts.
+func (p *Project) PromptAddition(command string) string {
+	var parts []string
+	for _, key := range []string{"all", command} {
+		if addition := p.PromptAdditions[key]; addition != "" {
+			parts = append(parts, addition)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// FindProjectFile walks up from dir and returns the first .genie.yaml.
+func FindProjectFile(dir string) (string, bool) {
+	for {
+		path := filepath.Join(dir, ProjectFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// ReadProject parses a .genie.yaml. Unknown keys are errors so that typos do
+// not go unnoticed.
+func ReadProject(path string) (*Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	project := &Project{Path: path}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(project); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+
+	if project.Engine != "" {
+		engine, exists := CheckAndGetEngine(project.Engine)
+		if !exists {
+			return nil, fmt.Errorf("invalid %s: unknown engine name: %s", path, project.Engine)
+		}
+		project.Engine = engine.Name
+	}
+	if project.BugOutputDir != "" && !filepath.IsAbs(project.BugOutputDir) {
+		project.BugOutputDir = filepath.Join(project.Dir(), project.BugOutputDir)
+	}
+	return project, nil
+}
+
+var (
+	projectOnce sync.Once
+	project     *Project
+	projectErr  error
+)
+
+// LoadProject returns the configuration of the project around the working
+// directory, or nil when there is none. It is read once per process.
+func LoadProject() (*Project, error) {
+	projectOnce.Do(func() {
+		cwd, err := os.Getwd()
+		if err != nil {
+			projectErr = err
+			return
+		}
+		path, found := FindProjectFile(cwd)
+		if !found {
+			return
+		}
+		project, projectErr = ReadProject(path)
+	})
+	return project, projectErr
+}
+
+// ResetProject makes the next LoadProject read the configuration again, e.g.
+// after the working directory changed.
+func ResetProject() {
+	projectOnce = sync.Once{}
+	project, projectErr = nil, nil
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeProject(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ProjectFileName)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadProject(t *testing.T) {
+	path := writeProject(t, `
+engine: gpt
+prompt_additions:
+  all: Python project.
+  do: Prefer poetry.
+bug_output_dir: docs/bugs
+safe_mode: true
+`)
+	project, err := ReadProject(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if project.Engine != GPTEngine {
+		t.Errorf("engine is %q, want %q", project.Engine, GPTEngine)
+	}
+	if want := filepath.Join(filepath.Dir(path), "docs", "bugs"); project.BugOutputDir != want {
+		t.Errorf("bug output dir is %q, want %q", project.BugOutputDir, want)
+	}
+	if project.SafeMode == nil || !*project.SafeMode {
+		t.Error("safe mode is not on")
+	}
+	if got := project.PromptAddition("do"); got != "Python project.\nPrefer poetry." {
+		t.Errorf("do prompt addition is %q", got)
+	}
+	if got := project.PromptAddition("tell"); got != "Python project." {
+		t.Errorf("tell prompt addition is %q", got)
+	}
+}
+
+func TestReadProjectErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		message string
+	}{
+		{"engnie: GPT\n", "field engnie not found"},
+		{"engine: Claude\n", "unknown engine name"},
+		{"safe_mode: maybe\n", "cannot unmarshal"},
+	}
+	for _, test := range tests {
+		_, err := ReadProject(writeProject(t, test.content))
+		if err == nil || !strings.Contains(err.Error(), test.message) {
+			t.Errorf("ReadProject(%q) = %v, want an error with %q", test.content, err, test.message)
+		}
+	}
+}
+
+func TestFindProjectFile(t *testing.T) {
+	path := writeProject(t, "")
+	nested := filepath.Join(filepath.Dir(path), "a", "b")
+	if err := os.MkdirAll(nested, 0700); err != nil {
+		t.Fatal(err)
+	}
+	found, ok := FindProjectFile(nested)
+	if !ok || found != path {
+		t.Errorf("FindProjectFile = %q, %v, want %q", found, ok, path)
+	}
+}
@@ -0,0 +1,248 @@
+// Package attach reads the files and piped input that are sent to the
+// engine along with a request.
+package attach
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxBytes is the most of a single attachment that is sent. Larger ones keep
+// their beginning and end, which is where logs and files tend to matter.
+const MaxBytes = 64 * 1024
+
+// MinFitBytes is the smallest share of the context an attachment is
+// shortened to by Fit. Less would leave too little of it to be of use.
+const MinFitBytes = 1024
+
+// StdinName is the name of the attachment read from piped input.
+const StdinName = "standard input"
+
+// Attachment is the content of a file, or of part of it, or of stdin.
+type Attachment struct {
+	// Name is the path as given, or StdinName.
+	Name string
+	// First and Last are the line range, 0 when the whole file is attached.
+	First, Last int
+	Content     string
+	// Omitted is the number of lines left out to stay under MaxBytes, or to
+	// fit into the context.
+	Omitted int
+}
+
+// Label names the attachment for the user and the engine, e.g.
+// "main.go (lines 10-80)".
+func (a Attachment) Label() string {
+	switch {
+	case a.First == 0:
+		return a.Name
+	case a.First == a.Last:
+		return fmt.Sprintf("%s (line %d)", a.Name, a.First)
+	default:
+		return fmt.Sprintf("%s (lines %d-%d)", a.Name, a.First, a.Last)
+	}
+}
+
+// rangeSuffix matches the line range of a spec: path:10, path:10-80 or
+// path:10- for line 10 to the end.
+var rangeSuffix = regexp.MustCompile(`^(.+):([0-9]+)(-([0-9]*))?$`)
+
+// ParseSpec splits a --file value into the path and the line range. A path
+// that exists as given is never split, so files with a colon and digits at
+// the end of their name still work.
+func ParseSpec(spec string) (path string, first, last int, err error) {
+	match := rangeSuffix.FindStringSubmatch(spec)
+	if match == nil {
+		return spec, 0, 0, nil
+	}
+	if _, err := os.Stat(spec); err == nil {
+		return spec, 0, 0, nil
+	}
+
+	path = match[1]
+	first, _ = strconv.Atoi(match[2])
+	switch {
+	case match[3] == "":
+		last = first
+	case match[4] == "":
+		// To the end of the file
+		last = -1
+	default:
+		last, _ = strconv.Atoi(match[4])
+	}
+	if first < 1 || (last != -1 && last < first) {
+		return "", 0, 0, errors.New("invalid line range, use path:10-80")
+	}
+	return path, first, last, nil
+}
+
+// File reads the file, or the line range of it, named by a --file spec.
+func File(spec string) (Attachment, error) {
+	path, first, last, err := ParseSpec(spec)
+	if err != nil {
+		return Attachment{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return Attachment{}, err
+	}
+	if info.IsDir() {
+		return Attachment{}, fmt.Errorf("%s is a directory", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Attachment{}, err
+	}
+	if isBinary(data) {
+		return Attachment{}, fmt.Errorf("%s looks like a binary file", path)
+	}
+
+	attachment := Attachment{Name: path}
+	content := string(data)
+	if first > 0 {
+		lines := strings.SplitAfter(content, "\n")
+		if lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		if first > len(lines) {
+			return Attachment{}, fmt.Errorf("%s has only %d lines", path, len(lines))
+		}
+		if last == -1 || last > len(lines) {
+			last = len(lines)
+		}
+		attachment.First, attachment.Last = first, last
+		content = strings.Join(lines[first-1:last], "")
+	}
+	attachment.Content, attachment.Omitted = shorten(content, MaxBytes)
+	return attachment, nil
+}
+
+// StdinPiped reports whether stdin is a pipe or a file rather than a
+// terminal, i.e. whether there is input to attach.
+func StdinPiped() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&(os.ModeNamedPipe|os.ModeCharDevice) == os.ModeNamedPipe || info.Mode().IsRegular()
+}
+
+// Stdin reads piped input. It returns false when stdin is a terminal or the
+// input is empty.
+func Stdin() (Attachment, bool, error) {
+	if !StdinPiped() {
+		return Attachment{}, false, nil
+	}
+	return read(StdinName, os.Stdin)
+}
+
+func read(name string, r io.Reader) (Attachment, bool, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Attachment{}, false, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return Attachment{}, false, nil
+	}
+	if isBinary(data) {
+		return Attachment{}, false, errors.New(name + " looks like binary data")
+	}
+	content, omitted := shorten(string(data), MaxBytes)
+	return Attachment{Name: name, Content: content, Omitted: omitted}, true, nil
+}
+
+// isBinary reports whether data is not text: it holds NUL bytes or is not
+// valid UTF-8 near the start.
+func isBinary(data []byte) bool {
+	head := data
+	if len(head) > 8000 {
+		head = head[:8000]
+		// Do not fail on a character cut in half
+		for i := 0; i < utf8.UTFMax && !utf8.Valid(head); i++ {
+			head = head[:len(head)-1]
+		}
+	}
+	return bytes.IndexByte(head, 0) >= 0 || !utf8.Valid(head)
+}
+
+// Fit shortens attachments that together are larger than limit bytes. The
+// smaller ones are kept whole when they can be, and the larger ones share
+// what is left equally, keeping their beginning and end. It reports false
+// when a share would be smaller than MinFitBytes.
+func Fit(attachments []Attachment, limit int) ([]Attachment, bool) {
+	total := 0
+	for _, attachment := range attachments {
+		total += len(attachment.Content)
+	}
+	if total <= limit {
+		return attachments, true
+	}
+
+	fitted := slices.Clone(attachments)
+	order := make([]int, len(fitted))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return len(fitted[a].Content) - len(fitted[b].Content) })
+
+	left := limit
+	for n, i := range order {
+		share := left / (len(order) - n)
+		if len(fitted[i].Content) > share {
+			if share < MinFitBytes {
+				return nil, false
+			}
+			content, omitted := shorten(fitted[i].Content, share)
+			fitted[i].Content = content
+			fitted[i].Omitted += omitted
+		}
+		left = max(0, left-len(fitted[i].Content))
+	}
+	return fitted, true
+}
+
+// shorten keeps the first and last lines of content that fit in max bytes
+// and reports how many lines it left out in between.
+func shorten(content string, max int) (string, int) {
+	if len(content) <= max {
+		return content, 0
+	}
+	lines := strings.SplitAfter(content, "\n")
+
+	var head, tail []string
+	size := 0
+	for i, j := 0, len(lines)-1; i <= j; {
+		// Alternate between the beginning and the end
+		line := lines[i]
+		if len(tail) < len(head) {
+			line = lines[j]
+		}
+		if size+len(line) > max {
+			break
+		}
+		size += len(line)
+		if len(tail) < len(head) {
+			tail = append([]string{line}, tail...)
+			j--
+		} else {
+			head = append(head, line)
+			i++
+		}
+	}
+
+	if len(head) == 0 {
+		// A single line longer than max, e.g. minified JSON
+		cut := strings.ToValidUTF8(content[:max/2], "") + "[...]" + strings.ToValidUTF8(content[len(content)-max/2:], "")
+		return cut, 1
+	}
+	omitted := len(lines) - len(head) - len(tail)
+	return strings.Join(head, "") + fmt.Sprintf("[... %d lines omitted ...]\n", omitted) + strings.Join(tail, ""), omitted
+}
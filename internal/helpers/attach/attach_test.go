@@ -0,0 +1,111 @@
+package attach
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseSpec(t *testing.T) {
+	dir := t.TempDir()
+	colonName := filepath.Join(dir, "backup:2024")
+	if err := os.WriteFile(colonName, []byte("x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		spec        string
+		path        string
+		first, last int
+		invalid     bool
+	}{
+		{"main.go", "main.go", 0, 0, false},
+		{"main.go:10", "main.go", 10, 10, false},
+		{"main.go:10-80", "main.go", 10, 80, false},
+		{"main.go:10-", "main.go", 10, -1, false},
+		{colonName, colonName, 0, 0, false},
+		{"main.go:0", "", 0, 0, true},
+		{"main.go:80-10", "", 0, 0, true},
+	}
+	for _, test := range tests {
+		path, first, last, err := ParseSpec(test.spec)
+		if test.invalid != (err != nil) {
+			t.Errorf("ParseSpec(%q) error = %v, want invalid %v", test.spec, err, test.invalid)
+			continue
+		}
+		if path != test.path || first != test.first || last != test.last {
+			t.Errorf("ParseSpec(%q) = %q, %d, %d, want %q, %d, %d", test.spec, path, first, last, test.path, test.first, test.last)
+		}
+	}
+}
+
+func TestShorten(t *testing.T) {
+	if content, omitted := shorten("short\n", 100); content != "short\n" || omitted != 0 {
+		t.Errorf("shorten kept %q and omitted %d lines of a short text", content, omitted)
+	}
+
+	var sb strings.Builder
+	for i := 0; i < 100; i++ {
+		sb.WriteString(strings.Repeat("x", 9) + "\n")
+	}
+	content, omitted := shorten("first line\n"+sb.String()+"last line\n", 200)
+	if !strings.HasPrefix(content, "first line\n") || !strings.HasSuffix(content, "last line\n") {
+		t.Errorf("shorten did not keep the first and last lines:\n%s", content)
+	}
+	if omitted == 0 || !strings.Contains(content, "lines omitted") {
+		t.Errorf("shorten omitted %d lines without saying so:\n%s", omitted, content)
+	}
+
+	long := strings.Repeat("a", 500)
+	if content, omitted := shorten(long, 100); len(content) > 110 || omitted != 1 {
+		t.Errorf("shorten of a single long line = %d bytes, %d omitted", len(content), omitted)
+	}
+}
+
+func TestFit(t *testing.T) {
+	lines := func(n int) string {
+		var sb strings.Builder
+		for i := range n {
+			fmt.Fprintf(&sb, "line %03d of the attachment\n", i)
+		}
+		return sb.String()
+	}
+	small := Attachment{Name: "small.txt", Content: lines(10)}
+	big := Attachment{Name: "big.log", Content: lines(1000)}
+	bigger := Attachment{Name: "bigger.log", Content: lines(2000)}
+
+	if fitted, ok := Fit([]Attachment{small, big}, 1<<20); !ok || fitted[1].Content != big.Content {
+		t.Error("Fit shortened attachments that fit")
+	}
+
+	limit := 10000
+	fitted, ok := Fit([]Attachment{bigger, small, big}, limit)
+	if !ok {
+		t.Fatal("Fit refused attachments it can shorten")
+	}
+	if fitted[1].Content != small.Content || fitted[1].Omitted != 0 {
+		t.Error("Fit shortened the small attachment, want it whole")
+	}
+	total := 0
+	for _, attachment := range fitted {
+		total += len(attachment.Content)
+	}
+	// Each shortened attachment adds a line saying what was left out
+	if total > limit+100 {
+		t.Errorf("the attachments are %d bytes, want about %d", total, limit)
+	}
+	for _, i := range []int{0, 2} {
+		if fitted[i].Omitted == 0 || !strings.HasPrefix(fitted[i].Content, "line 000") {
+			t.Errorf("%s kept %d bytes with %d lines omitted, want its beginning and end", fitted[i].Name, len(fitted[i].Content), fitted[i].Omitted)
+		}
+	}
+	if bigger.Omitted != 0 || bigger.Content != lines(2000) {
+		t.Error("Fit changed the attachments it was given")
+	}
+
+	if _, ok := Fit([]Attachment{big, bigger}, MinFitBytes); ok {
+		t.Error("Fit accepted shares smaller than MinFitBytes")
+	}
+}
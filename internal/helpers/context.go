@@ -0,0 +1,113 @@
+package helpers
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/harshalranjhani/genie/internal/config"
+	"github.com/harshalranjhani/genie/internal/helpers/usage"
+	"github.com/harshalranjhani/genie/internal/structs"
+)
+
+// MaxContextTokens caps the tokens spent on directory snapshots and git
+// information, even for models with a much larger context window.
+const MaxContextTokens = 20000
+
+// ContextBudget returns how many tokens of directory or git context fit into
+// a request to model next to a prompt of promptTokens, keeping a quarter of
+// the context window (up to 4096 tokens) free for the response.
+func ContextBudget(engineName string, model string, promptTokens int) int {
+	window := config.ContextWindow(engineName, model)
+	budget := window - min(window/4, 4096) - promptTokens
+	return max(0, min(budget, MaxContextTokens))
+}
+
+// RenderDirectory writes the directory snapshot within maxTokens. When the
+// full listing does not fit, deeper folders are replaced by their file count,
+// one level at a time, and as a last resort the listing is cut off. The
+// returned notes describe what was left out.
+func RenderDirectory(root structs.Directory, maxTokens int) (string, []string) {
+	var full strings.Builder
+	PrintData(&full, root, 0)
+	if usage.EstimateTokens(full.String()) <= maxTokens {
+		return full.String(), nil
+	}
+
+	maxDepth := 0
+	for _, file := range root.Files {
+		maxDepth = max(maxDepth, pathDepth(file.Name))
+	}
+
+	for depth := maxDepth - 1; depth >= 0; depth-- {
+		listing, summarizedFiles, summarizedDirs := renderDirectoryToDepth(root, depth)
+		if usage.EstimateTokens(listing) <= maxTokens {
+			return listing, []string{
+				fmt.Sprintf("directory snapshot limited to %d level(s): %d files in %d folders shown as counts", depth+1, summarizedFiles, summarizedDirs),
+			}
+		}
+	}
+
+	// Even the top level is too large, keep as many lines as fit
+	listing, summarizedFiles, summarizedDirs := renderDirectoryToDepth(root, 0)
+	lines := strings.SplitAfter(strings.TrimSuffix(listing, "\n"), "\n")
+	var sb strings.Builder
+	kept := 0
+	for _, line := range lines {
+		if usage.EstimateTokens(sb.String()+line) > maxTokens {
+			break
+		}
+		sb.WriteString(line)
+		kept++
+	}
+	dropped := len(lines) - kept
+	sb.WriteString(fmt.Sprintf("  ... %d more entries\n", dropped))
+
+	return sb.String(), []string{
+		fmt.Sprintf("directory snapshot cut to the top level: %d files in %d folders shown as counts, %d entries dropped", summarizedFiles, summarizedDirs, dropped),
+	}
+}
+
+// renderDirectoryToDepth lists the files at most depth folders deep and
+// summarizes deeper files by their folder at depth+1.
+func renderDirectoryToDepth(root structs.Directory, depth int) (string, int, int) {
+	type line struct {
+		text string
+		dir  string
+	}
+
+	var lines []line
+	counts := map[string]int{}
+	summarizedFiles := 0
+
+	for _, file := range root.Files {
+		name := filepath.ToSlash(file.Name)
+		if pathDepth(name) <= depth {
+			lines = append(lines, line{text: name})
+			continue
+		}
+
+		dir := strings.Join(strings.Split(name, "/")[:depth+1], "/")
+		if _, seen := counts[dir]; !seen {
+			lines = append(lines, line{dir: dir})
+		}
+		counts[dir]++
+		summarizedFiles++
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("[%s]\n", root.Name))
+	for _, l := range lines {
+		if l.dir != "" {
+			sb.WriteString(fmt.Sprintf("  - %s/ (%d files)\n", l.dir, counts[l.dir]))
+		} else {
+			sb.WriteString(fmt.Sprintf("  - %s\n", l.text))
+		}
+	}
+	return sb.String(), summarizedFiles, len(counts)
+}
+
+// pathDepth returns the number of folders above a relative file path.
+func pathDepth(path string) int {
+	return strings.Count(filepath.ToSlash(path), "/")
+}
@@ -4,7 +4,9 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -14,22 +16,20 @@ import (
 	"strings"
 
 	"github.com/fatih/color"
+	"github.com/harshalranjhani/genie/internal/config"
+	"github.com/harshalranjhani/genie/internal/secrets"
 	"github.com/harshalranjhani/genie/internal/structs"
-	"github.com/zalando/go-keyring"
+	"golang.org/x/term"
 )
 
 func GetCurrentDirectoriesAndFiles(root string) (structs.Directory, error) {
 	rootDir := structs.Directory{Name: root}
-	ignoreListPath, err := keyring.Get("genie", "ignore_list_path")
+	ignoreListPath, ignorePatterns, err := IgnorePatterns()
 	if err != nil {
-		return structs.Directory{}, fmt.Errorf("Error getting ignore list path: %w", err)
+		return structs.Directory{}, err
 	}
 	c := color.New(color.FgCyan).Add(color.Underline)
 	c.Println("Ignore List Path: ", ignoreListPath)
-	ignorePatterns, err := ReadIgnorePatterns(ignoreListPath)
-	if err != nil {
-		return structs.Directory{}, fmt.Errorf("Error reading ignore patterns: %w", err)
-	}
 
 	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -62,6 +62,20 @@ func GetCurrentDirectoriesAndFiles(root string) (structs.Directory, error) {
 	return rootDir, nil
 }
 
+// IgnorePatterns returns the path of the ignore list file and its patterns
+// together with those of the project's .genie.yaml.
+func IgnorePatterns() (string, []string, error) {
+	ignoreListPath, err := secrets.Get("ignore_list_path")
+	if err != nil {
+		return "", nil, fmt.Errorf("Error getting ignore list path: %w", err)
+	}
+	ignorePatterns, err := ReadIgnorePatterns(ignoreListPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("Error reading ignore patterns: %w", err)
+	}
+	return ignoreListPath, append(ignorePatterns, ProjectIgnorePatterns()...), nil
+}
+
 func ReadIgnorePatterns(filename string) ([]string, error) {
 	file, err := os.Open(filename)
 	if err != nil {
@@ -77,6 +91,16 @@ func ReadIgnorePatterns(filename string) ([]string, error) {
 	return patterns, scanner.Err()
 }
 
+// ProjectIgnorePatterns returns the ignore patterns of the project's
+// .genie.yaml, which add to those of the ignore list file.
+func ProjectIgnorePatterns() []string {
+	project, err := config.LoadProject()
+	if err != nil || project == nil {
+		return nil
+	}
+	return project.Ignore
+}
+
 func ShouldIgnore(path string, patterns []string) bool {
 	for _, pattern := range patterns {
 		matched, err := filepath.Match(pattern, filepath.Base(path))
@@ -108,8 +132,18 @@ func PrintData(sb *strings.Builder, root structs.Directory, level int) {
 	}
 }
 
+// commandShell is the shell RunCommand and RunCommandCapture run commands
+// with.
+var commandShell = "sh"
+
+// SetCommandShell makes RunCommand and RunCommandCapture use the given shell,
+// e.g. the user's zsh, instead of sh.
+func SetCommandShell(path string) {
+	commandShell = path
+}
+
 func RunCommand(command string) {
-	cmd := exec.Command("sh", "-c", command)
+	cmd := exec.Command(commandShell, "-c", command)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	err := cmd.Run()
@@ -118,6 +152,29 @@ func RunCommand(command string) {
 	}
 }
 
+// RunCommandCapture runs command like RunCommand and returns its exit code.
+// When stdout is a terminal the command keeps it, so that interactive
+// programs such as editors and pagers work, and only the exit code is
+// known. Otherwise what it printed is also returned. A failing command is
+// not an error.
+func RunCommandCapture(command string) (string, int, error) {
+	var output bytes.Buffer
+	cmd := exec.Command(commandShell, "-c", command)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		cmd.Stdout = io.MultiWriter(os.Stdout, &output)
+		cmd.Stderr = io.MultiWriter(os.Stderr, &output)
+	}
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return output.String(), exitErr.ExitCode(), nil
+	}
+	return output.String(), 0, err
+}
+
 func GenerateMarkdown(headings []structs.Heading, fileName string) {
 
 	// if headings is empty, return an error
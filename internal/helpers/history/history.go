@@ -0,0 +1,178 @@
+// Package history keeps the commands genie do ran, so they can be found and
+// run again.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/harshalranjhani/genie/internal/config"
+	"github.com/harshalranjhani/genie/internal/helpers"
+)
+
+// MaxOutput is how much of a command's output is kept. The end of the output
+// is kept, where errors and totals usually are.
+const MaxOutput = 4096
+
+// Entry is a command proposed by genie do and what happened when it ran.
+type Entry struct {
+	ID          int       `json:"id"`
+	Time        time.Time `json:"time"`
+	Prompt      string    `json:"prompt"`
+	Engine      string    `json:"engine"`
+	Model       string    `json:"model"`
+	Command     string    `json:"command"`
+	Explanation string    `json:"explanation,omitempty"`
+	Cwd         string    `json:"cwd"`
+	// Ran is false when the command was declined or blocked.
+	Ran      bool   `json:"ran"`
+	ExitCode int    `json:"exit_code"`
+	Output   string `json:"output,omitempty"`
+	// Step is the step of a genie do --agent run, counted from 1.
+	Step int `json:"step,omitempty"`
+	// RerunOf is the entry genie do rerun ran again.
+	RerunOf int `json:"rerun_of,omitempty"`
+}
+
+// Matches reports whether the prompt, command or directory of the entry
+// contains text, ignoring case.
+func (e Entry) Matches(text string) bool {
+	text = strings.ToLower(text)
+	for _, field := range []string{e.Prompt, e.Command, e.Cwd} {
+		if strings.Contains(strings.ToLower(field), text) {
+			return true
+		}
+	}
+	return false
+}
+
+func getHistoryPath() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "history.jsonl"), nil
+}
+
+// lockTimeout is how long Record waits for another genie process to finish
+// writing. A lock older than that was left behind by a process that died.
+const lockTimeout = 5 * time.Second
+
+// lock takes the lock file next to path, so that only one process at a time
+// numbers and appends entries. The returned function releases it.
+func lock(path string) (func(), error) {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > lockTimeout {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is held by another genie process", lockPath)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Record appends an entry to ~/.genie/history.jsonl and returns it with its
+// ID. Output longer than MaxOutput is cut. Concurrent genie processes take
+// turns, so that every entry gets its own ID.
+func Record(entry Entry) (Entry, error) {
+	path, err := getHistoryPath()
+	if err != nil {
+		return entry, err
+	}
+	unlock, err := lock(path)
+	if err != nil {
+		return entry, err
+	}
+	defer unlock()
+
+	entries, err := Load()
+	if err != nil {
+		return entry, err
+	}
+	entry.ID = 1
+	if len(entries) > 0 {
+		entry.ID = entries[len(entries)-1].ID + 1
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	if len(entry.Output) > MaxOutput {
+		entry.Output = "[earlier output omitted]\n" + helpers.SanitizeUTF8(entry.Output[len(entry.Output)-MaxOutput:])
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return entry, err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return entry, err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return entry, err
+}
+
+// Load returns all entries, oldest first.
+func Load() ([]Entry, error) {
+	path, err := getHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	// Entries carry up to MaxOutput bytes of output
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// Skip lines cut short by an interrupted write
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// Get returns the entry with the given ID.
+func Get(id int) (Entry, bool, error) {
+	entries, err := Load()
+	if err != nil {
+		return Entry{}, false, err
+	}
+	for _, entry := range entries {
+		if entry.ID == id {
+			return entry, true, nil
+		}
+	}
+	return Entry{}, false, nil
+}
@@ -0,0 +1,66 @@
+package history
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestRecordConcurrentIDs(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	const n = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := Record(Entry{Prompt: "list files", Command: "ls"}); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	entries, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != n {
+		t.Fatalf("got %d entries, want %d", len(entries), n)
+	}
+	seen := map[int]bool{}
+	for _, entry := range entries {
+		if seen[entry.ID] {
+			t.Errorf("ID %d was given twice", entry.ID)
+		}
+		seen[entry.ID] = true
+	}
+	for id := 1; id <= n; id++ {
+		if !seen[id] {
+			t.Errorf("ID %d is missing", id)
+		}
+	}
+}
+
+func TestRecordCutsOutput(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	output := make([]byte, MaxOutput*2)
+	for i := range output {
+		output[i] = 'x'
+	}
+	entry, err := Record(Entry{Command: "yes", Output: string(output)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entry.Output) > MaxOutput+100 {
+		t.Errorf("output is %d bytes, want about %d", len(entry.Output), MaxOutput)
+	}
+	if entry.ID != 1 {
+		t.Errorf("ID is %d, want 1", entry.ID)
+	}
+}
@@ -0,0 +1,332 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/chzyer/readline"
+	"github.com/fatih/color"
+	"github.com/harshalranjhani/genie/internal/constants"
+	"github.com/harshalranjhani/genie/internal/helpers"
+	"github.com/harshalranjhani/genie/internal/helpers/markdown"
+	"github.com/harshalranjhani/genie/internal/middleware"
+)
+
+var (
+	style = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#9D4EDD"))
+
+	promptStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#06D6A0")).
+			Bold(true)
+
+	reasoningStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFB703")).
+			Italic(true)
+
+	multilineStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#888888")).
+			Italic(true)
+)
+
+// StartChat runs an interactive chat session against the given provider.
+// Ctrl-C while a reply is generated stops only that reply; a positive
+// timeout limits how long each reply may take.
+func StartChat(p Provider, opts Options, timeout time.Duration) {
+	// Configure readline with multiline support
+	config := &readline.Config{
+		Prompt:                 promptStyle.Render("You 💭 > "),
+		HistoryFile:            fmt.Sprintf("/tmp/genie_%s_history", strings.ToLower(p.Name())),
+		HistoryLimit:           100,
+		DisableAutoSaveHistory: false,
+		InterruptPrompt:        "^C",
+		EOFPrompt:              "exit",
+		EnableMask:             false,
+	}
+	rl, err := readline.NewEx(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rl.Close()
+
+	printChatBanner()
+
+	var messages []Message
+
+	for {
+		// Read input with multiline support
+		var inputLines []string
+		isMultiline := false
+
+		for {
+			line, err := rl.Readline()
+			if err != nil {
+				if err == readline.ErrInterrupt {
+					if len(inputLines) > 0 {
+						// Cancel current multiline input
+						inputLines = nil
+						isMultiline = false
+						rl.SetPrompt(promptStyle.Render("You 💭 > "))
+						fmt.Println(style.Render("Input cancelled."))
+						break
+					}
+					continue
+				}
+				// EOF or other error - exit chat
+				fmt.Println(style.Render("\n👋 Ending chat session. Goodbye!"))
+				return
+			}
+
+			// Check for line continuation (backslash at end)
+			if strings.HasSuffix(line, "\\") {
+				inputLines = append(inputLines, strings.TrimSuffix(line, "\\"))
+				isMultiline = true
+				rl.SetPrompt(promptStyle.Render("  ... > "))
+				continue
+			}
+
+			// Check for multiline end marker
+			if isMultiline && strings.TrimSpace(line) == "---" {
+				rl.SetPrompt(promptStyle.Render("You 💭 > "))
+				break
+			}
+
+			inputLines = append(inputLines, line)
+
+			// If not in multiline mode, break after first line
+			if !isMultiline {
+				break
+			}
+		}
+
+		if len(inputLines) == 0 {
+			continue
+		}
+
+		userInput := strings.TrimSpace(strings.Join(inputLines, "\n"))
+		if userInput == "" {
+			continue
+		}
+
+		switch strings.ToLower(userInput) {
+		case constants.ExitCommand:
+			fmt.Println(style.Render("\n👋 Ending chat session. Goodbye!"))
+			return
+		case constants.ClearCommand:
+			messages = nil
+			// Clear terminal screen
+			fmt.Print("\033[H\033[2J")
+			printChatBanner()
+			continue
+		case constants.HistoryCommand:
+			exportChatHistory(messages)
+			continue
+		case constants.EmailCommand:
+			emailChatHistory(messages, p)
+			continue
+		}
+
+		messages = append(messages, Message{
+			Role:    constants.ChatMessageRoleUser,
+			Content: userInput,
+		})
+
+		ctx, cancel := RequestContext(timeout)
+		reply, err := streamChatReply(ctx, p, messages, opts)
+		cancel()
+
+		switch {
+		case errors.Is(err, context.Canceled):
+			fmt.Println("\n" + style.Render("⏹  Generation stopped."))
+		case errors.Is(err, context.DeadlineExceeded):
+			fmt.Printf("\n%s Reply timed out after %s\n", color.RedString("❌"), timeout)
+		case err != nil:
+			fmt.Printf("\n%s %v\n", color.RedString("❌"), err)
+		}
+
+		if reply == "" {
+			// Drop the unanswered message so the history stays consistent
+			messages = messages[:len(messages)-1]
+			fmt.Println(strings.Repeat("─", 50))
+			continue
+		}
+
+		// Keep the response in the history, even if it was interrupted
+		messages = append(messages, Message{
+			Role:    constants.ChatMessageRoleAssistant,
+			Content: reply,
+		})
+
+		fmt.Println("\n" + strings.Repeat("─", 50))
+	}
+}
+
+func printChatBanner() {
+	color.New(color.FgHiMagenta).Println("🧞 Chat session started!")
+	fmt.Println(style.Render("Commands: 'exit' | 'clear' | '/history' | '/email'"))
+	fmt.Println(multilineStyle.Render("Tip: For multiline input, type '\\' at end of line or use '---' on a new line to send."))
+	fmt.Println(strings.Repeat("─", 50))
+}
+
+// streamChatReply prints the reply as it arrives. When the stream fails or is
+// cancelled, the text received so far is returned along with the error.
+func streamChatReply(ctx context.Context, p Provider, messages []Message, opts Options) (string, error) {
+	s := spinner.New(spinner.CharSets[11], 80*time.Millisecond)
+	s.Prefix = color.HiCyanString("🤔 Thinking: ")
+	s.Suffix = " Please wait..."
+	s.Start()
+
+	stream, err := p.Chat(ctx, messages, opts)
+	s.Stop()
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+
+	if fallback, ok := p.(*FallbackProvider); ok && fallback.Notice() != "" {
+		color.Yellow("↪️  %s", fallback.Notice())
+	}
+
+	fmt.Println(color.HiCyanString("\n🤖 AI:"))
+	renderer := markdown.NewRenderer(os.Stdout)
+
+	var reasoning strings.Builder
+	var reply strings.Builder
+	var streamErr error
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			streamErr = err
+			break
+		}
+
+		reasoning.WriteString(chunk.Reasoning)
+		if chunk.Content != "" {
+			reply.WriteString(chunk.Content)
+			if err := renderer.Write(chunk.Content); err != nil {
+				streamErr = err
+				break
+			}
+		}
+	}
+	if err := renderer.Flush(); err != nil && streamErr == nil {
+		streamErr = err
+	}
+
+	// Print reasoning content only if the engine returned any
+	if reasoning.Len() > 0 {
+		fmt.Printf("\n%s\n", reasoningStyle.Render("💡 Reasoning:\n"+reasoning.String()))
+	}
+
+	return reply.String(), streamErr
+}
+
+func exportChatHistory(messages []Message) {
+	if len(messages) == 0 {
+		fmt.Printf("%s No chat history available to export.\n", color.RedString("❌"))
+		return
+	}
+
+	s := spinner.New(spinner.CharSets[35], 100*time.Millisecond)
+	s.Prefix = color.HiCyanString("📝 Exporting chat history: ")
+	s.Start()
+
+	timestamp := time.Now().Format("2006-01-02-15-04-05")
+	filename := filepath.Join(".", fmt.Sprintf("chat-history-%s.md", timestamp))
+
+	var content strings.Builder
+	content.WriteString("# Chat History\n\n")
+	content.WriteString(fmt.Sprintf("Generated on: %s\n\n", time.Now().Format("January 2, 2006 15:04:05")))
+	content.WriteString("---\n\n")
+
+	for _, msg := range messages {
+		switch msg.Role {
+		case constants.ChatMessageRoleUser:
+			content.WriteString(fmt.Sprintf("### 💭 You\n%s\n\n", msg.Content))
+		case constants.ChatMessageRoleAssistant:
+			content.WriteString(fmt.Sprintf("### 🤖 AI\n%s\n\n", msg.Content))
+		}
+		content.WriteString("---\n\n")
+	}
+
+	err := os.WriteFile(filename, []byte(content.String()), 0644)
+	s.Stop()
+
+	if err != nil {
+		fmt.Printf("%s Failed to export chat history: %v\n", color.RedString("❌"), err)
+		return
+	}
+
+	successMsg := fmt.Sprintf("✨ Chat history exported to: %s", filename)
+	fmt.Println(color.GreenString(successMsg))
+}
+
+func emailChatHistory(messages []Message, p Provider) {
+	if len(messages) == 0 {
+		fmt.Printf("%s No chat history available to email.\n", color.RedString("❌"))
+		return
+	}
+
+	// Create a divider for visual separation
+	fmt.Println(strings.Repeat("─", 50))
+	fmt.Println(color.HiMagentaString("📧 Emailing Chat History"))
+	fmt.Println(strings.Repeat("─", 50))
+
+	// Get user status to check for verified email
+	status, err := middleware.LoadStatus()
+	var email string
+	if err != nil || status == nil || status.Email == "" {
+		fmt.Print(color.YellowString("Please enter your email address: "))
+		fmt.Scanln(&email)
+	} else {
+		email = status.Email
+	}
+
+	s := spinner.New(spinner.CharSets[35], 100*time.Millisecond)
+	s.Prefix = color.HiCyanString("📝 Sending to ") + color.CyanString(email) + color.HiCyanString(": ")
+	s.Start()
+
+	var chatMessages []map[string]string
+	for _, msg := range messages {
+		chatMessages = append(chatMessages, map[string]string{
+			"role":    msg.Role,
+			"content": msg.Content,
+		})
+	}
+
+	payload := map[string]interface{}{
+		"timestamp": time.Now().Format(time.RFC3339),
+		"model":     p.Model(),
+		"messages":  chatMessages,
+		"metadata": map[string]string{
+			"sessionId": fmt.Sprintf("%s-%d", strings.ToLower(p.Name()), time.Now().Unix()),
+			"format":    "markdown",
+		},
+	}
+
+	if err := helpers.SendChatHistoryEmail(email, payload); err != nil {
+		s.Stop()
+		fmt.Printf("\n%s Failed to send chat history: %v\n", color.RedString("❌"), err)
+		fmt.Println(strings.Repeat("─", 50))
+		return
+	}
+
+	s.Stop()
+	fmt.Printf("\n%s Chat history sent successfully to %s!\n",
+		color.GreenString("✨"),
+		color.CyanString(email))
+	fmt.Println(strings.Repeat("─", 50))
+}
@@ -0,0 +1,25 @@
+package llm
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// RequestContext returns a context for a single engine request. It is
+// cancelled on Ctrl-C or SIGTERM and, when timeout is positive, once the
+// timeout elapses. The cancel function restores the default signal handling.
+func RequestContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	if timeout <= 0 {
+		return ctx, stop
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
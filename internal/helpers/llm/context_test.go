@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRequestContextTimeout(t *testing.T) {
+	ctx, cancel := RequestContext(0)
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("a request without a timeout has a deadline")
+	}
+	cancel()
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		t.Errorf("context after cancel: %v, want cancelled", ctx.Err())
+	}
+
+	ctx, cancel = RequestContext(20 * time.Millisecond)
+	defer cancel()
+	select {
+	case <-ctx.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("the request was not cancelled at its timeout")
+	}
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Errorf("context after the timeout: %v, want the deadline exceeded", ctx.Err())
+	}
+}
+
+func TestRequestContextInterrupt(t *testing.T) {
+	ctx, cancel := RequestContext(time.Minute)
+	defer cancel()
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := process.Signal(os.Interrupt); err != nil {
+		t.Skipf("cannot interrupt the test: %v", err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("the request was not cancelled by Ctrl-C")
+	}
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		t.Errorf("context after Ctrl-C: %v, want cancelled", ctx.Err())
+	}
+}
+
+func TestRequestTimeoutStopsRequest(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	// Timeouts are not retried, neither on the same engine nor on another
+	provider := withRetry(compatibleProvider(ts.URL+"/v1/", "", "llama3"), RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+	ctx, cancel := RequestContext(50 * time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := provider.Complete(ctx, "list files", Options{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Complete = %v, want the deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Complete returned after %s, want right after the timeout", elapsed)
+	}
+	if IsRetryable(err) || IsTransient(err) {
+		t.Errorf("the timeout %v is retried", err)
+	}
+}
+
+func TestRetryStopsWhenCancelled(t *testing.T) {
+	scripted := &scriptedProvider{name: "Gemini", errs: []error{
+		&StatusError{Engine: "Gemini", StatusCode: http.StatusServiceUnavailable},
+		&StatusError{Engine: "Gemini", StatusCode: http.StatusServiceUnavailable},
+	}}
+	provider := withRetry(scripted, RetryPolicy{MaxRetries: 1, BaseDelay: time.Minute, MaxDelay: time.Minute})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	_, err := provider.Complete(ctx, "list files", Options{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Complete cancelled while waiting to retry = %v, want cancelled", err)
+	}
+	if len(scripted.prompts) != 1 {
+		t.Errorf("sent %d requests, want no retry after the cancel", len(scripted.prompts))
+	}
+}
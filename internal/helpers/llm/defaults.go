@@ -0,0 +1,105 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/harshalranjhani/genie/internal/structs"
+)
+
+var (
+	defaultOptions Options
+	promptAddition string
+)
+
+// SetDefaultOptions sets generation defaults, such as those of the active
+// profile, for providers created afterwards. Only the temperature is applied,
+// and only to requests that do not set their own; safe mode stays a decision
+// of each command.
+func SetDefaultOptions(opts Options) {
+	defaultOptions = opts
+}
+
+// SetPromptAddition sets instructions, such as those of the project's
+// .genie.yaml, that are appended to every prompt of providers created
+// afterwards. In chats they extend the system prompt.
+func SetPromptAddition(addition string) {
+	promptAddition = addition
+}
+
+// withDefaults applies the generation defaults to every request.
+func withDefaults(provider Provider) Provider {
+	if defaultOptions.Temperature == 0 && promptAddition == "" {
+		return provider
+	}
+	return &defaultsProvider{Provider: provider, defaults: defaultOptions, addition: promptAddition}
+}
+
+type defaultsProvider struct {
+	Provider
+	defaults Options
+	addition string
+}
+
+func (d *defaultsProvider) extend(text string) string {
+	if d.addition == "" {
+		return text
+	}
+	if text == "" {
+		return d.addition
+	}
+	return text + "\n\n" + d.addition
+}
+
+func (d *defaultsProvider) apply(opts Options) Options {
+	if opts.Temperature == 0 {
+		opts.Temperature = d.defaults.Temperature
+	}
+	return opts
+}
+
+func (d *defaultsProvider) Complete(ctx context.Context, prompt string, opts Options) (string, error) {
+	return d.Provider.Complete(ctx, d.extend(prompt), d.apply(opts))
+}
+
+func (d *defaultsProvider) Stream(ctx context.Context, prompt string, opts Options) (Stream, error) {
+	return d.Provider.Stream(ctx, d.extend(prompt), d.apply(opts))
+}
+
+func (d *defaultsProvider) Chat(ctx context.Context, messages []Message, opts Options) (Stream, error) {
+	opts = d.apply(opts)
+	opts.System = d.extend(opts.System)
+	return d.Provider.Chat(ctx, messages, opts)
+}
+
+// withCaution adds Options.Caution to the safe mode requests of engines
+// without safety settings of their own.
+func withCaution(engine structs.Engine, provider Provider) Provider {
+	if engine.Features.SupportsSafeMode {
+		return provider
+	}
+	return &cautiousProvider{Provider: provider}
+}
+
+type cautiousProvider struct {
+	Provider
+}
+
+func caution(text string, opts Options) string {
+	if !opts.SafeMode || opts.Caution == "" {
+		return text
+	}
+	return text + opts.Caution
+}
+
+func (c *cautiousProvider) Complete(ctx context.Context, prompt string, opts Options) (string, error) {
+	return c.Provider.Complete(ctx, caution(prompt, opts), opts)
+}
+
+func (c *cautiousProvider) Stream(ctx context.Context, prompt string, opts Options) (Stream, error) {
+	return c.Provider.Stream(ctx, caution(prompt, opts), opts)
+}
+
+func (c *cautiousProvider) Chat(ctx context.Context, messages []Message, opts Options) (Stream, error) {
+	opts.System = caution(opts.System, opts)
+	return c.Provider.Chat(ctx, messages, opts)
+}
@@ -0,0 +1,34 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/harshalranjhani/genie/internal/config"
+)
+
+func TestWithCaution(t *testing.T) {
+	const caution = " Be careful."
+	tests := []struct {
+		engine   string
+		safeMode bool
+		want     bool
+	}{
+		{config.DeepSeekEngine, true, true},
+		{config.OllamaEngine, true, true},
+		{config.DeepSeekEngine, false, false},
+		{config.GeminiEngine, true, false},
+		{config.GPTEngine, true, false},
+	}
+	for _, test := range tests {
+		scripted := &scriptedProvider{name: test.engine, responses: []string{"ok"}}
+		provider := withCaution(config.EngineMap[test.engine], scripted)
+		if _, err := provider.Complete(context.Background(), "list files", Options{SafeMode: test.safeMode, Caution: caution}); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.HasSuffix(scripted.prompts[0], caution); got != test.want {
+			t.Errorf("%s with safe mode %v: prompt %q, want caution %v", test.engine, test.safeMode, scripted.prompts[0], test.want)
+		}
+	}
+}
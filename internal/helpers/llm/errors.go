@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+
+	"github.com/cohesion-org/deepseek-go"
+	"github.com/harshalranjhani/genie/internal/secrets"
+	"github.com/sashabaranov/go-openai"
+	"google.golang.org/genai"
+)
+
+// StatusError is returned for HTTP errors of engines that are called without
+// an SDK, such as Ollama.
+type StatusError struct {
+	Engine     string
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	status := fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message == "" {
+		return fmt.Sprintf("%s returned %s", strings.ToLower(e.Engine), status)
+	}
+	return fmt.Sprintf("%s returned %s: %s", strings.ToLower(e.Engine), status, e.Message)
+}
+
+// IsRetryable reports whether another engine might succeed where this one
+// failed: missing or rejected credentials, exhausted quota, rate limits,
+// server errors and unreachable servers. Cancellation, timeouts and bad
+// requests are not retryable.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrUnsafeContent) {
+		return false
+	}
+
+	// Credentials that were never stored
+	if errors.Is(err, secrets.ErrNotFound) {
+		return true
+	}
+
+	if code := statusCode(err); code != 0 {
+		switch {
+		case code == http.StatusUnauthorized,
+			code == http.StatusPaymentRequired,
+			code == http.StatusForbidden,
+			code == http.StatusTooManyRequests,
+			code >= 500:
+			return true
+		case code == http.StatusBadRequest:
+			// Gemini reports invalid and expired keys as bad requests
+			return strings.Contains(strings.ToLower(err.Error()), "api key")
+		}
+		return false
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// statusCode extracts the HTTP status code from the error types of the
+// engine SDKs, or returns 0 when err carries none.
+func statusCode(err error) int {
+	var openaiErr *openai.APIError
+	if errors.As(err, &openaiErr) {
+		return openaiErr.HTTPStatusCode
+	}
+	var requestErr *openai.RequestError
+	if errors.As(err, &requestErr) {
+		return requestErr.HTTPStatusCode
+	}
+	var geminiErr genai.APIError
+	if errors.As(err, &geminiErr) {
+		return geminiErr.Code
+	}
+	var deepseekErr deepseek.APIError
+	if errors.As(err, &deepseekErr) {
+		return deepseekErr.StatusCode
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode
+	}
+	return 0
+}
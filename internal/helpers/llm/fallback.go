@@ -0,0 +1,197 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Failure records an engine that could not answer a request.
+type Failure struct {
+	Engine string
+	Err    error
+}
+
+// FallbackProvider sends requests to the first engine of a chain and moves on
+// to the next one when a request fails with a retryable error. Name and Model
+// report the engine that answered the last request.
+type FallbackProvider struct {
+	engines   []string
+	model     string
+	providers map[string]Provider
+	current   Provider
+	failures  []Failure
+}
+
+// NewWithFallback returns a provider for engineName that falls back to the
+// engines of chain, in order, using their default models.
+func NewWithFallback(engineName string, model string, chain []string) *FallbackProvider {
+	engines := []string{engineName}
+	for _, name := range chain {
+		if name != engineName {
+			engines = append(engines, name)
+		}
+	}
+
+	return &FallbackProvider{
+		engines:   engines,
+		model:     model,
+		providers: map[string]Provider{},
+	}
+}
+
+func (f *FallbackProvider) Name() string {
+	if f.current != nil {
+		return f.current.Name()
+	}
+	return f.engines[0]
+}
+
+func (f *FallbackProvider) Model() string {
+	if f.current != nil {
+		return f.current.Model()
+	}
+	return f.model
+}
+
+// Failures returns the engines that failed before the last request was
+// answered.
+func (f *FallbackProvider) Failures() []Failure {
+	return f.failures
+}
+
+// Notice describes the fallback taken by the last request, or returns an
+// empty string when the first engine answered.
+func (f *FallbackProvider) Notice() string {
+	if len(f.failures) == 0 || f.current == nil {
+		return ""
+	}
+
+	var failed []string
+	for _, failure := range f.failures {
+		failed = append(failed, fmt.Sprintf("%s (%v)", failure.Engine, failure.Err))
+	}
+	return fmt.Sprintf("%s unavailable, answered by %s (%s)", strings.Join(failed, ", "), f.current.Name(), f.current.Model())
+}
+
+func (f *FallbackProvider) Complete(ctx context.Context, prompt string, opts Options) (string, error) {
+	var response string
+	err := f.try(func(p Provider) error {
+		var err error
+		response, err = p.Complete(ctx, prompt, opts)
+		return err
+	})
+	return response, err
+}
+
+func (f *FallbackProvider) Stream(ctx context.Context, prompt string, opts Options) (Stream, error) {
+	return f.openStream(func(p Provider) (Stream, error) {
+		return p.Stream(ctx, prompt, opts)
+	})
+}
+
+func (f *FallbackProvider) Chat(ctx context.Context, messages []Message, opts Options) (Stream, error) {
+	return f.openStream(func(p Provider) (Stream, error) {
+		return p.Chat(ctx, messages, opts)
+	})
+}
+
+// ListModels lists the models of the first engine only.
+func (f *FallbackProvider) ListModels(ctx context.Context) ([]string, error) {
+	p, err := f.provider(f.engines[0])
+	if err != nil {
+		return nil, err
+	}
+	return p.ListModels(ctx)
+}
+
+// openStream waits for the first chunk before settling on an engine, since
+// most engines only report quota and auth errors once the stream is read.
+// Errors after the first chunk are returned to the caller as they are.
+func (f *FallbackProvider) openStream(open func(Provider) (Stream, error)) (Stream, error) {
+	var result Stream
+	err := f.try(func(p Provider) error {
+		stream, err := open(p)
+		if err != nil {
+			return err
+		}
+
+		first, err := stream.Recv()
+		if err != nil && !errors.Is(err, io.EOF) {
+			stream.Close()
+			return err
+		}
+		result = &primedStream{Stream: stream, first: first, firstErr: err}
+		return nil
+	})
+	return result, err
+}
+
+func (f *FallbackProvider) try(call func(Provider) error) error {
+	f.failures = nil
+
+	var firstErr error
+	for i, engineName := range f.engines {
+		p, err := f.provider(engineName)
+		if err == nil {
+			err = call(p)
+			if err == nil {
+				f.current = p
+				return nil
+			}
+			if !IsRetryable(err) {
+				return err
+			}
+		} else if i == 0 && !IsRetryable(err) {
+			// Engines further down the chain are skipped when they are not
+			// set up, but the first engine has to be
+			return err
+		}
+
+		if firstErr == nil {
+			firstErr = err
+		}
+		f.failures = append(f.failures, Failure{Engine: engineName, Err: err})
+	}
+
+	if len(f.engines) > 1 {
+		return fmt.Errorf("no engine in the fallback chain could answer: %w", firstErr)
+	}
+	return firstErr
+}
+
+func (f *FallbackProvider) provider(engineName string) (Provider, error) {
+	if p, ok := f.providers[engineName]; ok {
+		return p, nil
+	}
+
+	model := ""
+	if engineName == f.engines[0] {
+		model = f.model
+	}
+
+	p, err := New(engineName, model)
+	if err != nil {
+		return nil, err
+	}
+	f.providers[engineName] = p
+	return p, nil
+}
+
+// primedStream replays the chunk read while choosing an engine.
+type primedStream struct {
+	Stream
+	first    Chunk
+	firstErr error
+	primed   bool
+}
+
+func (s *primedStream) Recv() (Chunk, error) {
+	if !s.primed {
+		s.primed = true
+		return s.first, s.firstErr
+	}
+	return s.Stream.Recv()
+}
@@ -0,0 +1,80 @@
+package llm
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/harshalranjhani/genie/internal/config"
+)
+
+// Model list sources reported by DiscoverModels.
+const (
+	ModelSourceLive     = "live"
+	ModelSourceCache    = "cache"
+	ModelSourceFallback = "fallback"
+)
+
+// ModelList is the result of model discovery for an engine.
+type ModelList struct {
+	Models []string
+	Source string
+	// Err is set when the provider could not be reached and a cached or
+	// static list was returned instead.
+	Err error
+}
+
+// DiscoverModels returns the models an engine can serve. A fresh cache entry
+// is used unless refresh is set; otherwise the provider's list endpoint is
+// queried and the result cached in ~/.genie. When the provider cannot be
+// reached, a stale cache entry or the static list is returned.
+func DiscoverModels(engineName string, refresh bool) ModelList {
+	engine, exists := config.CheckAndGetEngine(engineName)
+	if !exists {
+		return ModelList{Source: ModelSourceFallback, Err: fmt.Errorf("unknown engine name: %s", engineName)}
+	}
+
+	cached, hasCache := config.GetCachedModels(engine.Name)
+	if hasCache && cached.Fresh() && !refresh {
+		return ModelList{Models: cached.Models, Source: ModelSourceCache}
+	}
+
+	models, err := fetchModels(engine.Name)
+	if err == nil && len(models) > 0 {
+		// Failing to write the cache only costs a refetch next time
+		_ = config.SaveCachedModels(engine.Name, models)
+		return ModelList{Models: models, Source: ModelSourceLive}
+	}
+	if err == nil {
+		err = fmt.Errorf("%s returned no models", engine.Name)
+	}
+
+	if hasCache {
+		return ModelList{Models: cached.Models, Source: ModelSourceCache, Err: err}
+	}
+	return ModelList{Models: staticModels(engine.Name), Source: ModelSourceFallback, Err: err}
+}
+
+func fetchModels(engineName string) ([]string, error) {
+	factory, ok := providers[engineName]
+	if !ok {
+		return nil, fmt.Errorf("no provider registered for engine %s", engineName)
+	}
+
+	// The model is irrelevant for listing, any placeholder will do
+	provider, err := factory(config.EngineMap[engineName].DefaultModel)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := RequestContext(15 * time.Second)
+	defer cancel()
+	return provider.ListModels(ctx)
+}
+
+// staticModels is the offline fallback for engines that cannot be reached.
+func staticModels(engineName string) []string {
+	if engineName == config.OpenAICompatibleEngine {
+		return GetOpenAICompatibleModels()
+	}
+	return config.EngineMap[engineName].Models
+}
@@ -0,0 +1,95 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/harshalranjhani/genie/internal/config"
+)
+
+// listingProvider answers ListModels with models or err and counts the calls.
+type listingProvider struct {
+	scriptedProvider
+	models []string
+	err    error
+	calls  int
+}
+
+func (p *listingProvider) ListModels(ctx context.Context) ([]string, error) {
+	p.calls++
+	return p.models, p.err
+}
+
+func TestDiscoverModels(t *testing.T) {
+	static := config.EngineMap[config.GeminiEngine].Models
+	stale := config.CachedModels{Models: []string{"gemini-stale"}, FetchedAt: time.Now().Add(-2 * config.ModelCacheTTL)}
+	fresh := config.CachedModels{Models: []string{"gemini-cached"}, FetchedAt: time.Now()}
+
+	tests := []struct {
+		name    string
+		cache   *config.CachedModels
+		refresh bool
+		models  []string
+		err     error
+		// want is the list discovered, calls how often the engine was asked
+		want    []string
+		source  string
+		calls   int
+		wantErr bool
+	}{
+		{name: "no cache", models: []string{"gemini-live"}, want: []string{"gemini-live"}, source: ModelSourceLive, calls: 1},
+		{name: "fresh cache", cache: &fresh, models: []string{"gemini-live"}, want: fresh.Models, source: ModelSourceCache},
+		{name: "refresh", cache: &fresh, refresh: true, models: []string{"gemini-live"}, want: []string{"gemini-live"}, source: ModelSourceLive, calls: 1},
+		{name: "stale cache", cache: &stale, models: []string{"gemini-live"}, want: []string{"gemini-live"}, source: ModelSourceLive, calls: 1},
+		{name: "unreachable with cache", cache: &stale, err: errors.New("connection refused"), want: stale.Models, source: ModelSourceCache, calls: 1, wantErr: true},
+		{name: "unreachable", err: errors.New("connection refused"), want: static, source: ModelSourceFallback, calls: 1, wantErr: true},
+		{name: "no models", want: static, source: ModelSourceFallback, calls: 1, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			if test.cache != nil {
+				data, err := json.Marshal(map[string]config.CachedModels{config.GeminiEngine: *test.cache})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.MkdirAll(filepath.Join(home, ".genie"), 0700); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(home, ".genie", "models_cache.json"), data, 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			engine := &listingProvider{models: test.models, err: test.err}
+			useFactory(t, config.GeminiEngine, func(model string) (Provider, error) { return engine, nil })
+
+			list := DiscoverModels(config.GeminiEngine, test.refresh)
+			if !slices.Equal(list.Models, test.want) || list.Source != test.source || (list.Err != nil) != test.wantErr {
+				t.Errorf("DiscoverModels = %q from %s with error %v, want %q from %s with error %v", list.Models, list.Source, list.Err, test.want, test.source, test.wantErr)
+			}
+			if engine.calls != test.calls {
+				t.Errorf("asked the engine %d times, want %d", engine.calls, test.calls)
+			}
+
+			// A live list is cached for the next discovery
+			cached, _ := config.GetCachedModels(config.GeminiEngine)
+			if test.source == ModelSourceLive && !slices.Equal(cached.Models, test.want) {
+				t.Errorf("cached %q after a live discovery, want %q", cached.Models, test.want)
+			}
+		})
+	}
+}
+
+func TestDiscoverModelsUnknownEngine(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if list := DiscoverModels("NoSuchEngine", false); list.Err == nil || len(list.Models) > 0 {
+		t.Errorf("DiscoverModels of an unknown engine = %+v, want an error and no models", list)
+	}
+}
@@ -0,0 +1,73 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/harshalranjhani/genie/internal/config"
+	"github.com/harshalranjhani/genie/internal/secrets"
+	"github.com/sashabaranov/go-openai"
+)
+
+func init() {
+	Register(config.OpenAICompatibleEngine, newOpenAICompatibleProvider)
+}
+
+// GetOpenAICompatibleModels returns the models configured for the
+// OpenAI-compatible engine.
+func GetOpenAICompatibleModels() []string {
+	list, err := secrets.Get("openai_compatible_models")
+	if err != nil {
+		return nil
+	}
+
+	var models []string
+	for _, model := range strings.Split(list, ",") {
+		if model = strings.TrimSpace(model); model != "" {
+			models = append(models, model)
+		}
+	}
+	return models
+}
+
+var errNoCompatibleModel = errors.New("no models configured for the OpenAI-compatible engine: please run `genie reset` to set the model list")
+
+func newOpenAICompatibleProvider(model string) (Provider, error) {
+	baseURL, err := secrets.Get("openai_compatible_url")
+	if err != nil || baseURL == "" {
+		return nil, fmt.Errorf("OpenAI-compatible base URL not configured: please run `genie init` or `genie reset` to set it")
+	}
+
+	// The API key is optional, most local servers ignore it
+	apiKey, _ := secrets.Get("openai_compatible_api_key")
+
+	// Without a configured model the provider can still list the models the
+	// server offers; requests fail with errNoCompatibleModel instead
+	if model == "" {
+		models := GetOpenAICompatibleModels()
+		if cached, ok := config.GetCachedModels(config.OpenAICompatibleEngine); ok && len(models) == 0 {
+			models = cached.Models
+		}
+		if len(models) > 0 {
+			model = models[0]
+		}
+	}
+
+	return compatibleProvider(baseURL, apiKey, model), nil
+}
+
+// compatibleProvider talks to the server at baseURL with none of the OpenAI
+// extensions, which local servers often reject.
+func compatibleProvider(baseURL, apiKey, model string) *gptProvider {
+	clientConfig := openai.DefaultConfig(apiKey)
+	clientConfig.BaseURL = strings.TrimSuffix(baseURL, "/")
+	clientConfig.HTTPClient = httpClient
+
+	return &gptProvider{
+		name:    config.OpenAICompatibleEngine,
+		client:  openai.NewClientWithConfig(clientConfig),
+		model:   model,
+		noModel: errNoCompatibleModel,
+	}
+}
@@ -0,0 +1,110 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// compatibleServer stands in for a local OpenAI-compatible server that
+// rejects the OpenAI extensions genie must not send it.
+type compatibleServer struct {
+	mu       sync.Mutex
+	requests []map[string]any
+	paths    []string
+}
+
+func (s *compatibleServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.paths = append(s.paths, r.URL.Path)
+	s.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/v1/models":
+		fmt.Fprint(w, `{"object": "list", "data": [{"id": "llama3"}, {"id": "nomic-embed-text"}]}`)
+	case "/v1/chat/completions":
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.requests = append(s.requests, body)
+		s.mu.Unlock()
+		for _, field := range []string{"stream_options", "response_format"} {
+			if _, ok := body[field]; ok {
+				http.Error(w, `{"error": {"message": "unknown field `+field+`"}}`, http.StatusBadRequest)
+				return
+			}
+		}
+
+		if body["stream"] == true {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: {\"choices\": [{\"index\": 0, \"delta\": {\"content\": \"hel\"}}]}\n\n")
+			fmt.Fprint(w, "data: {\"choices\": [{\"index\": 0, \"delta\": {\"content\": \"lo\"}}]}\n\n")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		fmt.Fprint(w, `{"choices": [{"index": 0, "message": {"role": "assistant", "content": "{\"command\": \"ls\"}"}}], "usage": {"prompt_tokens": 12, "completion_tokens": 4}}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestCompatibleProvider(t *testing.T) {
+	server := &compatibleServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	provider := compatibleProvider(ts.URL+"/v1/", "", "llama3")
+	ctx := context.Background()
+
+	// Safe mode must not reach for the OpenAI moderation endpoint, and the
+	// schema must not turn into response_format
+	response, err := provider.Complete(ctx, "list files", Options{SafeMode: true, Schema: &Schema{Type: "object"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response != `{"command": "ls"}` {
+		t.Errorf("Complete = %q", response)
+	}
+
+	stream, err := provider.Chat(ctx, []Message{{Role: "user", Content: "say hello"}}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text, err := ReadAll(stream); err != nil || text != "hello" {
+		t.Errorf("Chat = %q, %v, want hello", text, err)
+	}
+
+	models, err := provider.ListModels(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(models, []string{"llama3", "nomic-embed-text"}) {
+		t.Errorf("ListModels = %v, want every model of the server", models)
+	}
+
+	if slices.Contains(server.paths, "/v1/moderations") {
+		t.Error("the moderation endpoint was called")
+	}
+	if len(server.requests) != 2 || server.requests[0]["model"] != "llama3" {
+		t.Errorf("requests are %v, want two for llama3", server.requests)
+	}
+
+	unconfigured := compatibleProvider(ts.URL+"/v1", "", "")
+	if _, err := unconfigured.Complete(ctx, "list files", Options{}); !errors.Is(err, errNoCompatibleModel) {
+		t.Errorf("err = %v, want errNoCompatibleModel", err)
+	}
+
+	// Other engines leave a missing model to the server
+	gpt := &gptProvider{name: "GPT", client: unconfigured.client}
+	if _, err := gpt.Complete(ctx, "list files", Options{}); errors.Is(err, errNoCompatibleModel) {
+		t.Error("GPT reported errNoCompatibleModel")
+	}
+}
@@ -0,0 +1,131 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/harshalranjhani/genie/internal/config"
+	"github.com/harshalranjhani/genie/internal/constants"
+)
+
+// ErrUnsafeContent is returned when a provider's safety checks reject a response.
+var ErrUnsafeContent = errors.New("inappropriate content detected")
+
+// Message is a single turn of a conversation.
+type Message struct {
+	Role    string
+	Content string
+}
+
+// Options tune a single request. Zero values fall back to the engine defaults.
+type Options struct {
+	System      string
+	Temperature float32
+	SafeMode    bool
+	// Caution is added to the prompt in safe mode when the engine that
+	// answers has no safety settings of its own. With a fallback chain, each
+	// engine tried decides for itself.
+	Caution string
+	// Schema asks for a JSON response that follows it, see CompleteJSON.
+	// Engines without a structured output mode ignore it.
+	Schema *Schema
+}
+
+// Chunk is a piece of a streamed response. Reasoning is only set by engines
+// that expose their chain of thought, such as deepseek-reasoner.
+type Chunk struct {
+	Content   string
+	Reasoning string
+}
+
+// Stream yields response chunks until Recv returns io.EOF.
+type Stream interface {
+	Recv() (Chunk, error)
+	Close() error
+}
+
+// Provider is implemented by every engine genie can talk to. Providers never
+// print or exit; they return the response or an error to the caller.
+type Provider interface {
+	// Name returns the engine name as registered in config.EngineMap.
+	Name() string
+	// Model returns the model requests are sent to.
+	Model() string
+	// Complete sends a single prompt and returns the whole response.
+	Complete(ctx context.Context, prompt string, opts Options) (string, error)
+	// Stream sends a single prompt and streams the response back.
+	Stream(ctx context.Context, prompt string, opts Options) (Stream, error)
+	// Chat continues a multi-turn conversation and streams the reply.
+	Chat(ctx context.Context, messages []Message, opts Options) (Stream, error)
+	// ListModels asks the engine which models it can currently serve.
+	ListModels(ctx context.Context) ([]string, error)
+}
+
+// Factory creates a provider for the given model.
+type Factory func(model string) (Provider, error)
+
+var providers = map[string]Factory{}
+
+// Register makes a provider available for an engine in config.EngineMap.
+// It is meant to be called from the init function of each engine file.
+func Register(engineName string, factory Factory) {
+	if _, ok := config.EngineMap[engineName]; !ok {
+		panic(fmt.Sprintf("llm: engine %q is not listed in config.EngineMap", engineName))
+	}
+	providers[engineName] = factory
+}
+
+// New returns the provider for engineName. An empty model selects the
+// engine's default model.
+func New(engineName string, model string) (Provider, error) {
+	engine, exists := config.CheckAndGetEngine(engineName)
+	if !exists {
+		return nil, fmt.Errorf("unknown engine name: %s", engineName)
+	}
+
+	factory, ok := providers[engine.Name]
+	if !ok {
+		return nil, fmt.Errorf("no provider registered for engine %s", engine.Name)
+	}
+
+	if model == "" {
+		model = engine.DefaultModel
+	}
+
+	provider, err := factory(model)
+	if err != nil {
+		return nil, err
+	}
+	// Metering sits below the retries, so that every attempt is recorded
+	return withCaution(engine, withDefaults(withRetry(&meteredProvider{Provider: provider}, retryPolicy))), nil
+}
+
+// ReadAll drains the stream and returns the concatenated content.
+func ReadAll(s Stream) (string, error) {
+	defer s.Close()
+
+	var sb strings.Builder
+	for {
+		chunk, err := s.Recv()
+		if errors.Is(err, io.EOF) {
+			return sb.String(), nil
+		}
+		if err != nil {
+			return sb.String(), err
+		}
+		sb.WriteString(chunk.Content)
+	}
+}
+
+// promptMessages wraps a single prompt into a conversation.
+func promptMessages(prompt string) []Message {
+	return []Message{
+		{
+			Role:    constants.ChatMessageRoleUser,
+			Content: prompt,
+		},
+	}
+}
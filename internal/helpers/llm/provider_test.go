@@ -0,0 +1,87 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/harshalranjhani/genie/internal/config"
+)
+
+// useFactory registers factory for engineName until the test ends.
+func useFactory(t *testing.T, engineName string, factory Factory) {
+	t.Helper()
+	previous, ok := providers[engineName]
+	providers[engineName] = factory
+	t.Cleanup(func() {
+		if ok {
+			providers[engineName] = previous
+		} else {
+			delete(providers, engineName)
+		}
+	})
+}
+
+func TestEveryEngineIsRegistered(t *testing.T) {
+	for name := range config.EngineMap {
+		if _, ok := providers[name]; !ok {
+			t.Errorf("no provider registered for engine %s", name)
+		}
+	}
+}
+
+func TestRegisterUnknownEngine(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Register of an engine missing from config.EngineMap did not panic")
+		}
+	}()
+	Register("NoSuchEngine", func(model string) (Provider, error) { return nil, nil })
+}
+
+func TestNew(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var models []string
+	useFactory(t, config.GeminiEngine, func(model string) (Provider, error) {
+		models = append(models, model)
+		if model == "broken" {
+			return nil, errors.New("no API key")
+		}
+		return &scriptedProvider{name: config.GeminiEngine, responses: []string{"hello"}}, nil
+	})
+
+	tests := []struct {
+		engine    string
+		model     string
+		wantModel string
+		wantErr   bool
+	}{
+		{engine: config.GeminiEngine, model: "", wantModel: config.EngineMap[config.GeminiEngine].DefaultModel},
+		{engine: config.GeminiEngine, model: "gemini-custom", wantModel: "gemini-custom"},
+		{engine: "gemini", model: "gemini-custom", wantModel: "gemini-custom"},
+		{engine: config.GeminiEngine, model: "broken", wantModel: "broken", wantErr: true},
+		{engine: "NoSuchEngine", wantErr: true},
+	}
+	for _, test := range tests {
+		models = nil
+		provider, err := New(test.engine, test.model)
+		if (err != nil) != test.wantErr {
+			t.Errorf("New(%q, %q) error = %v, want error %v", test.engine, test.model, err, test.wantErr)
+			continue
+		}
+		if test.wantModel != "" && (len(models) != 1 || models[0] != test.wantModel) {
+			t.Errorf("New(%q, %q) created models %q, want %q", test.engine, test.model, models, test.wantModel)
+		}
+		if err != nil {
+			continue
+		}
+
+		if provider.Name() != config.GeminiEngine {
+			t.Errorf("New(%q, %q).Name() = %q, want %q", test.engine, test.model, provider.Name(), config.GeminiEngine)
+		}
+		response, err := provider.Complete(context.Background(), "say hello", Options{})
+		if err != nil || response != "hello" {
+			t.Errorf("New(%q, %q).Complete() = %q, %v, want the engine's response", test.engine, test.model, response, err)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// RetryPolicy controls how engine calls that fail with a transient error,
+// such as a rate limit or an overloaded server, are retried.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt.
+	MaxRetries int
+	// BaseDelay is the wait before the first retry; it doubles on every retry.
+	BaseDelay time.Duration
+	// MaxDelay caps a single wait. A Retry-After longer than MaxDelay stops
+	// retrying altogether.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is used by New unless SetRetryPolicy is called.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 2,
+	BaseDelay:  time.Second,
+	MaxDelay:   30 * time.Second,
+}
+
+var retryPolicy = DefaultRetryPolicy
+
+// SetRetryPolicy changes the retry policy of providers created afterwards.
+func SetRetryPolicy(policy RetryPolicy) {
+	retryPolicy = policy
+}
+
+// httpClient is shared by the engines so the retry layer can read the
+// Retry-After headers that SDK errors do not expose.
+var httpClient = &http.Client{Transport: &retryAfterTransport{base: http.DefaultTransport}}
+
+type retryHintKey struct{}
+
+// retryHint carries the server's requested wait from the transport back to
+// the retry loop through the request context.
+type retryHint struct {
+	mu    sync.Mutex
+	after time.Duration
+}
+
+func (h *retryHint) set(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.after = d
+}
+
+func (h *retryHint) take() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	d := h.after
+	h.after = 0
+	return d
+}
+
+type retryAfterTransport struct {
+	base http.RoundTripper
+}
+
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	if hint, ok := req.Context().Value(retryHintKey{}).(*retryHint); ok {
+		if d, ok := parseRetryAfter(resp.Header); ok {
+			hint.set(d)
+		}
+	}
+	return resp, nil
+}
+
+// parseRetryAfter reads the OpenAI specific retry-after-ms header and the
+// standard Retry-After header in both its seconds and HTTP-date forms.
+func parseRetryAfter(header http.Header) (time.Duration, bool) {
+	if ms, err := strconv.ParseFloat(header.Get("Retry-After-Ms"), 64); err == nil {
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date), true
+	}
+	return 0, false
+}
+
+// geminiRetryDelay reads the RetryInfo detail Gemini attaches to quota errors.
+func geminiRetryDelay(err error) (time.Duration, bool) {
+	var geminiErr genai.APIError
+	if !errors.As(err, &geminiErr) {
+		return 0, false
+	}
+
+	for _, detail := range geminiErr.Details {
+		kind, _ := detail["@type"].(string)
+		delay, _ := detail["retryDelay"].(string)
+		if strings.HasSuffix(kind, "RetryInfo") && delay != "" {
+			if d, err := time.ParseDuration(delay); err == nil {
+				return d, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// IsTransient reports whether repeating the same request to the same engine
+// may succeed: rate limits, overloaded or failing servers, timeouts and
+// dropped connections.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if code := statusCode(err); code != 0 {
+		switch code {
+		case http.StatusRequestTimeout,
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+			529: // overloaded
+			return true
+		}
+		return false
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retryProvider retries the calls of a provider according to a RetryPolicy.
+type retryProvider struct {
+	Provider
+	policy RetryPolicy
+}
+
+func withRetry(p Provider, policy RetryPolicy) Provider {
+	if policy.MaxRetries <= 0 {
+		return p
+	}
+	return &retryProvider{Provider: p, policy: policy}
+}
+
+func (r *retryProvider) Complete(ctx context.Context, prompt string, opts Options) (string, error) {
+	var response string
+	err := r.do(ctx, func(ctx context.Context) error {
+		var err error
+		response, err = r.Provider.Complete(ctx, prompt, opts)
+		return err
+	})
+	return response, err
+}
+
+func (r *retryProvider) Stream(ctx context.Context, prompt string, opts Options) (Stream, error) {
+	return r.openStream(ctx, func(ctx context.Context) (Stream, error) {
+		return r.Provider.Stream(ctx, prompt, opts)
+	})
+}
+
+func (r *retryProvider) Chat(ctx context.Context, messages []Message, opts Options) (Stream, error) {
+	return r.openStream(ctx, func(ctx context.Context) (Stream, error) {
+		return r.Provider.Chat(ctx, messages, opts)
+	})
+}
+
+func (r *retryProvider) ListModels(ctx context.Context) ([]string, error) {
+	var models []string
+	err := r.do(ctx, func(ctx context.Context) error {
+		var err error
+		models, err = r.Provider.ListModels(ctx)
+		return err
+	})
+	return models, err
+}
+
+// openStream only retries until the first chunk arrives; once output has
+// been shown to the user the stream is handed over as it is.
+func (r *retryProvider) openStream(ctx context.Context, open func(context.Context) (Stream, error)) (Stream, error) {
+	var result Stream
+	err := r.do(ctx, func(ctx context.Context) error {
+		stream, err := open(ctx)
+		if err != nil {
+			return err
+		}
+
+		first, err := stream.Recv()
+		if err != nil && !errors.Is(err, io.EOF) {
+			stream.Close()
+			return err
+		}
+		result = &primedStream{Stream: stream, first: first, firstErr: err}
+		return nil
+	})
+	return result, err
+}
+
+func (r *retryProvider) do(ctx context.Context, call func(context.Context) error) error {
+	hint := &retryHint{}
+	ctx = context.WithValue(ctx, retryHintKey{}, hint)
+
+	for attempt := 0; ; attempt++ {
+		err := call(ctx)
+		if err == nil || attempt >= r.policy.MaxRetries || !IsTransient(err) {
+			return err
+		}
+
+		delay, ok := r.delay(attempt, err, hint)
+		if !ok {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// delay returns the wait before the given retry: the server's Retry-After
+// when it sent one, otherwise exponential backoff with jitter. It returns
+// false when the server asks for a longer wait than the policy allows.
+func (r *retryProvider) delay(attempt int, err error, hint *retryHint) (time.Duration, bool) {
+	requested := hint.take()
+	if d, ok := geminiRetryDelay(err); ok {
+		requested = d
+	}
+	if requested > 0 {
+		return requested, requested <= r.policy.MaxDelay
+	}
+
+	backoff := r.policy.BaseDelay << attempt
+	if backoff <= 0 || backoff > r.policy.MaxDelay {
+		backoff = r.policy.MaxDelay
+	}
+	// Wait between half and the full backoff so concurrent clients spread out
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1)), true
+}
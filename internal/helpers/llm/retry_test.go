@@ -0,0 +1,60 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+		ok     bool
+	}{
+		{"none", http.Header{}, 0, false},
+		{"seconds", http.Header{"Retry-After": {"3"}}, 3 * time.Second, true},
+		{"milliseconds", http.Header{"Retry-After-Ms": {"1500"}}, 1500 * time.Millisecond, true},
+		{"milliseconds win", http.Header{"Retry-After-Ms": {"200"}, "Retry-After": {"9"}}, 200 * time.Millisecond, true},
+		{"invalid", http.Header{"Retry-After": {"soon"}}, 0, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(test.header)
+			if got != test.want || ok != test.ok {
+				t.Errorf("parseRetryAfter = %v, %v, want %v, %v", got, ok, test.want, test.ok)
+			}
+		})
+	}
+
+	date := http.Header{"Retry-After": {time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)}}
+	if got, ok := parseRetryAfter(date); !ok || got <= 50*time.Second || got > time.Minute {
+		t.Errorf("parseRetryAfter(HTTP date) = %v, %v, want about a minute", got, ok)
+	}
+}
+
+func TestRetryAfterTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	hint := &retryHint{}
+	ctx := context.WithValue(context.Background(), retryHintKey{}, hint)
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := hint.take(); got != 7*time.Second {
+		t.Errorf("hint is %v, want 7s", got)
+	}
+}
@@ -0,0 +1,151 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// ErrInvalidJSON is returned by CompleteJSON when the engine did not answer
+// with the JSON that was asked for, even when asked a second time.
+var ErrInvalidJSON = errors.New("the engine did not return valid JSON")
+
+// Schema describes the JSON a response must follow. Engines with a
+// structured output mode are held to it; the others only see the prompt.
+// Only the parts of JSON Schema genie needs are covered: objects, strings,
+// booleans and arrays.
+type Schema struct {
+	// Type is "object", "string", "boolean" or "array".
+	Type        string
+	Description string
+	Enum        []string
+	// Properties and Required describe an object. Every property is
+	// required, in the order listed.
+	Properties map[string]*Schema
+	Required   []string
+	// Items describes the elements of an array.
+	Items *Schema
+}
+
+// JSONSchema returns the schema in JSON Schema form, e.g. for Ollama.
+func (s *Schema) JSONSchema() map[string]interface{} {
+	result := map[string]interface{}{"type": s.Type}
+	if s.Description != "" {
+		result["description"] = s.Description
+	}
+	if len(s.Enum) > 0 {
+		result["enum"] = s.Enum
+	}
+	if len(s.Properties) > 0 {
+		properties := map[string]interface{}{}
+		for name, property := range s.Properties {
+			properties[name] = property.JSONSchema()
+		}
+		result["properties"] = properties
+		result["required"] = s.Required
+	}
+	if s.Items != nil {
+		result["items"] = s.Items.JSONSchema()
+	}
+	return result
+}
+
+// geminiSchema converts the schema to the OpenAPI subset Gemini accepts.
+func (s *Schema) geminiSchema() *genai.Schema {
+	result := &genai.Schema{
+		Type:        genai.Type(strings.ToUpper(s.Type)),
+		Description: s.Description,
+		Enum:        s.Enum,
+	}
+	if len(s.Properties) > 0 {
+		result.Properties = map[string]*genai.Schema{}
+		for name, property := range s.Properties {
+			result.Properties[name] = property.geminiSchema()
+		}
+		result.Required = s.Required
+		// Gemini otherwise sorts the properties alphabetically
+		result.PropertyOrdering = s.Required
+	}
+	if s.Items != nil {
+		result.Items = s.Items.geminiSchema()
+	}
+	return result
+}
+
+// CompleteJSON sends prompt, asking for a response that follows schema, and
+// decodes it into v. A response wrapped in Markdown fences or prose, or with
+// trailing commas, is repaired. If the response still does not decode, or
+// validate rejects it, the engine is asked once more with the error.
+func CompleteJSON(ctx context.Context, provider Provider, prompt string, schema *Schema, opts Options, v interface{}, validate func() error) error {
+	opts.Schema = schema
+	response, err := provider.Complete(ctx, prompt, opts)
+	if err != nil {
+		return err
+	}
+	problem := decodeResponse(response, v, validate)
+	if problem == nil {
+		return nil
+	}
+
+	retry := fmt.Sprintf("%s\n\nYour previous response was:\n%s\n\nIt was rejected: %v. Respond again with only the JSON object, without Markdown fences or any other text.", prompt, response, problem)
+	response, err = provider.Complete(ctx, retry, opts)
+	if err != nil {
+		return err
+	}
+	if problem := decodeResponse(response, v, validate); problem != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidJSON, problem)
+	}
+	return nil
+}
+
+func decodeResponse(response string, v interface{}, validate func() error) error {
+	// Leave nothing of an earlier, rejected response behind
+	reflect.ValueOf(v).Elem().SetZero()
+	if err := DecodeJSON(response, v); err != nil {
+		return err
+	}
+	if validate != nil {
+		return validate()
+	}
+	return nil
+}
+
+var (
+	jsonFence     = regexp.MustCompile("(?s)```[a-zA-Z]*\\s*(.*?)```")
+	trailingComma = regexp.MustCompile(`,\s*([}\]])`)
+	smartQuotes   = strings.NewReplacer("“", `"`, "”", `"`)
+)
+
+// DecodeJSON decodes the JSON object in a model response into v. It accepts
+// the object inside Markdown fences or surrounded by prose, and repairs
+// trailing commas and typographic quotes.
+func DecodeJSON(response string, v interface{}) error {
+	text := strings.TrimSpace(response)
+	if err := json.Unmarshal([]byte(text), v); err == nil {
+		return nil
+	}
+
+	if match := jsonFence.FindStringSubmatch(text); match != nil {
+		text = strings.TrimSpace(match[1])
+	}
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return errors.New("the response contains no JSON object")
+	}
+	text = text[start : end+1]
+	if err := json.Unmarshal([]byte(text), v); err == nil {
+		return nil
+	}
+
+	text = trailingComma.ReplaceAllString(smartQuotes.Replace(text), "$1")
+	if err := json.Unmarshal([]byte(text), v); err != nil {
+		return fmt.Errorf("the response is not valid JSON: %w", err)
+	}
+	return nil
+}
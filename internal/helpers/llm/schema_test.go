@@ -0,0 +1,140 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// scriptedProvider answers Complete with its responses in turn and records
+// the prompts it was sent.
+type scriptedProvider struct {
+	name      string
+	responses []string
+	errs      []error
+	prompts   []string
+	opts      []Options
+}
+
+func (p *scriptedProvider) Name() string  { return p.name }
+func (p *scriptedProvider) Model() string { return p.name + "-model" }
+
+func (p *scriptedProvider) Complete(ctx context.Context, prompt string, opts Options) (string, error) {
+	i := len(p.prompts)
+	p.prompts = append(p.prompts, prompt)
+	p.opts = append(p.opts, opts)
+	if i < len(p.errs) && p.errs[i] != nil {
+		return "", p.errs[i]
+	}
+	if i >= len(p.responses) {
+		return "", errors.New("no more responses")
+	}
+	return p.responses[i], nil
+}
+
+func (p *scriptedProvider) Stream(ctx context.Context, prompt string, opts Options) (Stream, error) {
+	return nil, errors.New("not supported")
+}
+
+func (p *scriptedProvider) Chat(ctx context.Context, messages []Message, opts Options) (Stream, error) {
+	return nil, errors.New("not supported")
+}
+
+func (p *scriptedProvider) ListModels(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+type testProposal struct {
+	Command string `json:"command"`
+	Risk    string `json:"risk"`
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+	}{
+		{"plain", `{"command": "ls", "risk": "low"}`},
+		{"fenced", "```json\n{\"command\": \"ls\", \"risk\": \"low\"}\n```"},
+		{"prose", "Sure! Here it is:\n{\"command\": \"ls\", \"risk\": \"low\"}\nHope that helps."},
+		{"trailing comma", `{"command": "ls", "risk": "low",}`},
+		{"smart quotes", `{“command”: “ls”, “risk”: “low”}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var proposal testProposal
+			if err := DecodeJSON(test.response, &proposal); err != nil {
+				t.Fatal(err)
+			}
+			if proposal.Command != "ls" || proposal.Risk != "low" {
+				t.Errorf("decoded %+v", proposal)
+			}
+		})
+	}
+
+	var proposal testProposal
+	if err := DecodeJSON("I cannot help with that.", &proposal); err == nil {
+		t.Error("decoding prose without JSON succeeded")
+	}
+}
+
+func TestCompleteJSON(t *testing.T) {
+	schema := &Schema{Type: "object"}
+	tests := []struct {
+		name      string
+		responses []string
+		calls     int
+		command   string
+		invalid   bool
+	}{
+		{"valid", []string{`{"command": "ls", "risk": "low"}`}, 1, "ls", false},
+		{"repaired", []string{"```\n{\"command\": \"ls\", \"risk\": \"low\",}\n```"}, 1, "ls", false},
+		{"asked again after bad JSON", []string{"ls -la", `{"command": "ls -la", "risk": "low"}`}, 2, "ls -la", false},
+		{"asked again after validation", []string{`{"command": "", "risk": "low"}`, `{"command": "pwd", "risk": "low"}`}, 2, "pwd", false},
+		{"gives up", []string{"no", "still no"}, 2, "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := &scriptedProvider{name: "test", responses: test.responses}
+			var proposal testProposal
+			validate := func() error {
+				if proposal.Command == "" {
+					return errors.New(`"command" is empty`)
+				}
+				return nil
+			}
+			err := CompleteJSON(context.Background(), provider, "list files", schema, Options{}, &proposal, validate)
+			if test.invalid != errors.Is(err, ErrInvalidJSON) {
+				t.Fatalf("err = %v, want invalid %v", err, test.invalid)
+			}
+			if !test.invalid && err != nil {
+				t.Fatal(err)
+			}
+			if len(provider.prompts) != test.calls {
+				t.Errorf("sent %d requests, want %d", len(provider.prompts), test.calls)
+			}
+			if proposal.Command != test.command {
+				t.Errorf("command is %q, want %q", proposal.Command, test.command)
+			}
+			for _, opts := range provider.opts {
+				if opts.Schema != schema {
+					t.Error("the schema was not passed to the provider")
+				}
+			}
+			if test.calls == 2 && !strings.Contains(provider.prompts[1], "It was rejected") {
+				t.Errorf("the second prompt does not say why the first response was rejected:\n%s", provider.prompts[1])
+			}
+		})
+	}
+}
+
+func TestCompleteJSONRequestError(t *testing.T) {
+	failure := errors.New("connection refused")
+	provider := &scriptedProvider{name: "test", errs: []error{failure}}
+	var proposal testProposal
+	err := CompleteJSON(context.Background(), provider, "list files", &Schema{Type: "object"}, Options{}, &proposal, nil)
+	if !errors.Is(err, failure) || errors.Is(err, ErrInvalidJSON) {
+		t.Errorf("err = %v, want the request error", err)
+	}
+}
@@ -0,0 +1,184 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/harshalranjhani/genie/internal/helpers/usage"
+)
+
+var (
+	usageCommand string
+	usageBudget  usage.Budget
+)
+
+// SetUsageCommand sets the command name recorded in the usage ledger for
+// requests made afterwards.
+func SetUsageCommand(command string) {
+	usageCommand = command
+}
+
+// SetBudget sets the monthly budget checked before every request.
+func SetBudget(budget usage.Budget) {
+	usageBudget = budget
+}
+
+type usageKey struct{}
+
+// tokenCount receives the token usage an engine reports for a request. It is
+// passed to the engine through the request context.
+type tokenCount struct {
+	mu         sync.Mutex
+	prompt     int
+	completion int
+	reported   bool
+}
+
+// reportUsage stores the token usage reported by an engine. Engines that
+// report running totals while streaming may call it repeatedly.
+func reportUsage(ctx context.Context, prompt, completion int) {
+	counts, ok := ctx.Value(usageKey{}).(*tokenCount)
+	if !ok || prompt+completion == 0 {
+		return
+	}
+
+	counts.mu.Lock()
+	defer counts.mu.Unlock()
+	counts.prompt = prompt
+	counts.completion = completion
+	counts.reported = true
+}
+
+// meteredProvider records every request in the usage ledger, including the
+// failed ones, and enforces the monthly budget. It wraps the engine itself,
+// so each retry is a request of its own.
+type meteredProvider struct {
+	Provider
+}
+
+func (m *meteredProvider) Complete(ctx context.Context, prompt string, opts Options) (string, error) {
+	if _, err := usageBudget.Check(); err != nil {
+		return "", err
+	}
+
+	counts := &tokenCount{}
+	response, err := m.Provider.Complete(context.WithValue(ctx, usageKey{}, counts), prompt, opts)
+	m.record(counts, opts.System+prompt, response, err)
+	return response, err
+}
+
+func (m *meteredProvider) Stream(ctx context.Context, prompt string, opts Options) (Stream, error) {
+	return m.openStream(ctx, opts.System+prompt, func(ctx context.Context) (Stream, error) {
+		return m.Provider.Stream(ctx, prompt, opts)
+	})
+}
+
+func (m *meteredProvider) Chat(ctx context.Context, messages []Message, opts Options) (Stream, error) {
+	var sb strings.Builder
+	sb.WriteString(opts.System)
+	for _, msg := range messages {
+		sb.WriteString(msg.Content)
+	}
+
+	return m.openStream(ctx, sb.String(), func(ctx context.Context) (Stream, error) {
+		return m.Provider.Chat(ctx, messages, opts)
+	})
+}
+
+func (m *meteredProvider) openStream(ctx context.Context, prompt string, open func(context.Context) (Stream, error)) (Stream, error) {
+	if _, err := usageBudget.Check(); err != nil {
+		return nil, err
+	}
+
+	counts := &tokenCount{}
+	stream, err := open(context.WithValue(ctx, usageKey{}, counts))
+	if err != nil {
+		m.record(counts, prompt, "", err)
+		return nil, err
+	}
+
+	return &meteredStream{
+		Stream:   stream,
+		provider: m,
+		counts:   counts,
+		prompt:   prompt,
+	}, nil
+}
+
+// record writes a ledger entry for a request that ended with err, estimating
+// the token counts from the text when the engine did not report them. A
+// failed request is only charged for what it reported or generated. Failing
+// to write the ledger never fails the request.
+func (m *meteredProvider) record(counts *tokenCount, prompt, response string, err error) {
+	counts.mu.Lock()
+	entry := usage.Entry{
+		Command:          usageCommand,
+		Engine:           m.Name(),
+		Model:            m.Model(),
+		Status:           usage.StatusOK,
+		PromptTokens:     counts.prompt,
+		CompletionTokens: counts.completion,
+	}
+	reported := counts.reported
+	counts.mu.Unlock()
+
+	if err != nil {
+		entry.Status, entry.Error = usage.StatusFailed, err.Error()
+	}
+	if !reported && (err == nil || response != "") {
+		entry.PromptTokens = usage.EstimateTokens(prompt)
+		entry.CompletionTokens = usage.EstimateTokens(response)
+		entry.Estimated = true
+	}
+
+	_ = usage.Record(entry)
+}
+
+// meteredStream records the request once the stream ends, fails or is
+// closed, including the partial response of an interrupted stream.
+type meteredStream struct {
+	Stream
+	provider *meteredProvider
+	counts   *tokenCount
+	prompt   string
+	response strings.Builder
+	done     bool
+}
+
+func (s *meteredStream) Recv() (Chunk, error) {
+	chunk, err := s.Stream.Recv()
+	s.response.WriteString(chunk.Content)
+	s.response.WriteString(chunk.Reasoning)
+	if errors.Is(err, io.EOF) {
+		s.finish(nil)
+	} else if err != nil {
+		s.finish(err)
+	}
+	return chunk, err
+}
+
+func (s *meteredStream) Close() error {
+	s.finish(nil)
+	return s.Stream.Close()
+}
+
+func (s *meteredStream) finish(err error) {
+	if s.done {
+		return
+	}
+	s.done = true
+
+	s.counts.mu.Lock()
+	reported := s.counts.reported
+	s.counts.mu.Unlock()
+
+	// A stream closed before anything was generated was not a request worth
+	// recording
+	if err == nil && !reported && s.response.Len() == 0 {
+		return
+	}
+	s.provider.record(s.counts, s.prompt, s.response.String(), err)
+}
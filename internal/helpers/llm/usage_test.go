@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/harshalranjhani/genie/internal/helpers/usage"
+)
+
+func TestMeteredProviderRecordsEveryAttempt(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	scripted := &scriptedProvider{
+		name:      "test",
+		errs:      []error{io.ErrUnexpectedEOF, nil},
+		responses: []string{"", "hello"},
+	}
+	provider := withRetry(&meteredProvider{Provider: scripted}, RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+	response, err := provider.Complete(context.Background(), "say hello", Options{})
+	if err != nil || response != "hello" {
+		t.Fatalf("Complete = %q, %v, want hello", response, err)
+	}
+
+	failing := &meteredProvider{Provider: &scriptedProvider{name: "down", errs: []error{errors.New("invalid API key")}}}
+	if _, err := failing.Complete(context.Background(), "say hello", Options{}); err == nil {
+		t.Fatal("Complete succeeded, want an error")
+	}
+
+	entries, err := usage.Load(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		engine string
+		status string
+		tokens bool
+	}{
+		{"test", usage.StatusFailed, false},
+		{"test", usage.StatusOK, true},
+		{"down", usage.StatusFailed, false},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("recorded %d entries, want %d: %+v", len(entries), len(want), entries)
+	}
+	for i, w := range want {
+		entry := entries[i]
+		if entry.Engine != w.engine || entry.Status != w.status || (entry.TotalTokens() > 0) != w.tokens {
+			t.Errorf("entry %d is %+v, want engine %s, status %s, tokens %v", i, entry, w.engine, w.status, w.tokens)
+		}
+		if entry.Failed() && entry.Error == "" {
+			t.Errorf("entry %d failed without an error", i)
+		}
+	}
+}
@@ -5,14 +5,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"math"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
 	"unicode/utf8"
 
 	"github.com/fatih/color"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/harshalranjhani/genie/internal/helpers/usage"
 	"github.com/harshalranjhani/genie/pkg/assets"
 )
 
@@ -68,14 +72,20 @@ func ProcessTemplateResponse(templateName string, generatedText string, readmePa
 	return nil
 }
 
-func GetGitInfo(path string) (string, error) {
-	repo, err := git.PlainOpen(path)
+// GetGitInfo describes the repository at path within maxTokens: the branch,
+// uncommitted changes, diff statistics, the diff itself and recent commits.
+// When the diff does not fit, the diffs of the changed files closest to path
+// are kept and the others are left out. The returned notes describe what was
+// left out.
+func GetGitInfo(path string, maxTokens int) (string, []string, error) {
+	repo, err := git.PlainOpenWithOptions(path, &git.PlainOpenOptions{DetectDotGit: true})
 	if err != nil {
 		color.Red("Error: Git repository not found in current directory")
-		return "", fmt.Errorf("failed to open repository: %w", err)
+		return "", nil, fmt.Errorf("failed to open repository: %w", err)
 	}
 
 	var info strings.Builder
+	var notes []string
 	hasError := false
 
 	// Get current branch
@@ -87,41 +97,8 @@ func GetGitInfo(path string) (string, error) {
 		info.WriteString(fmt.Sprintf("Current Branch: %s\n", head.Name().Short()))
 	}
 
-	// Get status using git command
-	status, err := exec.Command("git", "status", "--porcelain").Output()
-	if err != nil {
-		color.Yellow("Warning: Could not get git status information")
-		hasError = true
-	} else if len(status) > 0 {
-		info.WriteString("\nUncommitted Changes:\n")
-		info.WriteString(string(status))
-
-		// Get diff with size limit and histogram
-		diffStats, err := exec.Command("git", "diff", "--stat").Output()
-		if err != nil {
-			color.Yellow("Warning: Could not get git diff statistics")
-		} else {
-			info.WriteString("\nDiff Statistics:\n")
-			info.WriteString(string(diffStats))
-		}
-
-		diff, err := exec.Command("git", "diff").Output()
-		if err != nil {
-			color.Yellow("Warning: Could not get git diff information")
-		} else {
-			diffStr := string(diff)
-			if len(diffStr) > 12000 {
-				info.WriteString("\nDiff (truncated - too large):\n")
-				info.WriteString(diffStr[:12000])
-				info.WriteString("\n... (diff truncated, see statistics above for full change overview)")
-			} else {
-				info.WriteString("\nDiff:\n")
-				info.WriteString(diffStr)
-			}
-		}
-	}
-
 	// Get recent commits only if we have head reference
+	var recentCommits strings.Builder
 	if head != nil {
 		commits, err := repo.Log(&git.LogOptions{
 			From:  head.Hash(),
@@ -132,13 +109,13 @@ func GetGitInfo(path string) (string, error) {
 			color.Yellow("Warning: Could not get commit history")
 			hasError = true
 		} else {
-			info.WriteString("\nRecent Commits:\n")
+			recentCommits.WriteString("\nRecent Commits:\n")
 			count := 0
 			err = commits.ForEach(func(c *object.Commit) error {
 				if count >= 5 {
 					return fmt.Errorf("done")
 				}
-				info.WriteString(fmt.Sprintf("- %s: %s\n", c.Hash.String()[:7], c.Message))
+				recentCommits.WriteString(fmt.Sprintf("- %s: %s\n", c.Hash.String()[:7], c.Message))
 				count++
 				return nil
 			})
@@ -149,8 +126,132 @@ func GetGitInfo(path string) (string, error) {
 		}
 	}
 
+	// Get status using git command
+	var diff string
+	status, err := exec.Command("git", "status", "--porcelain").Output()
+	if err != nil {
+		color.Yellow("Warning: Could not get git status information")
+		hasError = true
+	} else if len(status) > 0 {
+		info.WriteString("\nUncommitted Changes:\n")
+		info.WriteString(string(status))
+
+		// Get diff with size limit and histogram
+		diffStats, err := exec.Command("git", "diff", "--stat").Output()
+		if err != nil {
+			color.Yellow("Warning: Could not get git diff statistics")
+		} else {
+			info.WriteString("\nDiff Statistics:\n")
+			info.WriteString(string(diffStats))
+		}
+
+		rawDiff, err := exec.Command("git", "diff").Output()
+		if err != nil {
+			color.Yellow("Warning: Could not get git diff information")
+		} else {
+			diffBudget := maxTokens - usage.EstimateTokens(info.String()+recentCommits.String())
+			var diffNotes []string
+			diff, diffNotes = fitDiff(string(rawDiff), repoRoot(repo, path), path, diffBudget)
+			notes = append(notes, diffNotes...)
+		}
+	}
+
+	info.WriteString(diff)
+	info.WriteString(recentCommits.String())
+
 	if hasError {
-		return info.String(), fmt.Errorf("completed with some errors")
+		return info.String(), notes, fmt.Errorf("completed with some errors")
+	}
+	return info.String(), notes, nil
+}
+
+func repoRoot(repo *git.Repository, fallback string) string {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fallback
+	}
+	return worktree.Filesystem.Root()
+}
+
+// fileDiff is the part of a git diff that belongs to a single file.
+type fileDiff struct {
+	path     string
+	text     string
+	distance int
+}
+
+// fitDiff keeps the file diffs closest to cwd that fit into maxTokens. A
+// single diff that is too large on its own is cut off rather than dropped.
+func fitDiff(diff string, root string, cwd string, maxTokens int) (string, []string) {
+	if diff == "" {
+		return "", nil
+	}
+	if usage.EstimateTokens(diff) <= maxTokens {
+		return "\nDiff:\n" + diff, nil
+	}
+
+	files := splitDiff(diff)
+	for i := range files {
+		files[i].distance = pathDistance(cwd, filepath.Join(root, files[i].path))
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].distance < files[j].distance })
+
+	var sb strings.Builder
+	var omitted []string
+	remaining := maxTokens
+	for _, file := range files {
+		tokens := usage.EstimateTokens(file.text)
+		switch {
+		case tokens <= remaining:
+			sb.WriteString(file.text)
+			remaining -= tokens
+		case sb.Len() == 0 && remaining > 200:
+			// Keep the start of the closest change instead of nothing
+			runes := []rune(file.text)
+			sb.WriteString(string(runes[:min(len(runes), remaining*4)]))
+			sb.WriteString("\n... (diff of " + file.path + " truncated)\n")
+			remaining = 0
+		default:
+			omitted = append(omitted, file.path)
+		}
+	}
+
+	if len(omitted) == 0 {
+		return "\nDiff (truncated - too large):\n" + sb.String(), []string{"git diff truncated to fit the context window"}
+	}
+
+	sb.WriteString("\n... (diffs omitted, see statistics above: " + strings.Join(omitted, ", ") + ")\n")
+	return "\nDiff (closest changes only - too large):\n" + sb.String(), []string{
+		fmt.Sprintf("git diff: kept %d of %d changed files, omitted %s", len(files)-len(omitted), len(files), strings.Join(omitted, ", ")),
+	}
+}
+
+// splitDiff splits the output of git diff into one part per file.
+func splitDiff(diff string) []fileDiff {
+	var files []fileDiff
+	for _, part := range strings.Split("\n"+diff, "\ndiff --git ")[1:] {
+		text := "diff --git " + part
+		if !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+
+		// The header reads "diff --git a/<path> b/<path>"
+		header, _, _ := strings.Cut(part, "\n")
+		path := header
+		if i := strings.Index(header, " b/"); i >= 0 {
+			path = header[i+3:]
+		}
+		files = append(files, fileDiff{path: path, text: text})
+	}
+	return files
+}
+
+// pathDistance counts the folders between dir and file: the levels up to a
+// common parent plus the levels down to the file.
+func pathDistance(dir string, file string) int {
+	rel, err := filepath.Rel(dir, file)
+	if err != nil {
+		return math.MaxInt
 	}
-	return info.String(), nil
+	return strings.Count(filepath.ToSlash(rel), "/")
 }
@@ -0,0 +1,36 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFitDiff(t *testing.T) {
+	diffOf := func(path string, lines int) string {
+		return "diff --git a/" + path + " b/" + path + "\n" + strings.Repeat("+"+path+" changed\n", lines)
+	}
+	diff := diffOf("docs/guide.md", 200) + diffOf("cmd/app/main.go", 20) + diffOf("cmd/app/flags.go", 20)
+
+	if text, notes := fitDiff(diff, "/repo", "/repo/cmd/app", 1_000_000); !strings.HasPrefix(text, "\nDiff:\n") || len(notes) > 0 {
+		t.Errorf("a diff that fits was changed: %v", notes)
+	}
+
+	text, notes := fitDiff(diff, "/repo", "/repo/cmd/app", 500)
+	if !strings.Contains(text, "cmd/app/main.go changed") || !strings.Contains(text, "cmd/app/flags.go changed") {
+		t.Errorf("the diffs closest to the working directory were not kept:\n%s", text)
+	}
+	if strings.Contains(text, "docs/guide.md changed") {
+		t.Error("the distant diff was kept although it does not fit")
+	}
+	if len(notes) != 1 || !strings.Contains(notes[0], "omitted docs/guide.md") {
+		t.Errorf("notes are %v, want the omitted file", notes)
+	}
+
+	text, notes = fitDiff(diffOf("big.go", 2000), "/repo", "/repo", 300)
+	if !strings.Contains(text, "truncated") || len(notes) != 1 {
+		t.Errorf("a single large diff was not cut off: %v", notes)
+	}
+	if text, _ := fitDiff("", "/repo", "/repo", 300); text != "" {
+		t.Errorf("an empty diff became %q", text)
+	}
+}
@@ -0,0 +1,197 @@
+// Package markdown renders Markdown responses for the terminal while they
+// stream in.
+package markdown
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// maxWidth keeps long lines readable on wide terminals.
+const maxWidth = 120
+
+// Renderer writes Markdown to a terminal as it streams in. Headings and the
+// lines of a paragraph are rendered as soon as they are complete. Lists,
+// tables, quotes and indented code are rendered once a blank line ends them,
+// and a fenced code block once it is closed, so formatting split across
+// chunks is never broken. When the output is not a terminal, or colors are
+// off, the text is written as it arrives.
+type Renderer struct {
+	out   io.Writer
+	term  *glamour.TermRenderer
+	line  strings.Builder
+	block strings.Builder
+	// fence is the fence of the open code block, e.g. "```"
+	fence string
+	// rendered is set once a block was written
+	rendered bool
+	// paragraph is set when the last line written belongs to a paragraph
+	// that has not ended yet, so the next line continues it
+	paragraph bool
+	// midLine is set when the plain output does not end with a newline
+	midLine bool
+}
+
+// NewRenderer returns a renderer writing to out.
+func NewRenderer(out *os.File) *Renderer {
+	r := &Renderer{out: out}
+	fd := int(out.Fd())
+	if color.NoColor || !term.IsTerminal(fd) {
+		return r
+	}
+
+	width := 80
+	if w, _, err := term.GetSize(fd); err == nil && w > 0 {
+		width = min(w, maxWidth)
+	}
+	style := glamour.WithStandardStyle("dark")
+	if os.Getenv("GLAMOUR_STYLE") != "" {
+		style = glamour.WithEnvironmentConfig()
+	}
+	renderer, err := glamour.NewTermRenderer(style, glamour.WithWordWrap(width-4))
+	if err == nil {
+		r.term = renderer
+	}
+	return r
+}
+
+// Write adds a chunk of the response and renders the blocks it completes.
+func (r *Renderer) Write(chunk string) error {
+	if r.term == nil {
+		if chunk != "" {
+			r.midLine = !strings.HasSuffix(chunk, "\n")
+		}
+		_, err := io.WriteString(r.out, chunk)
+		return err
+	}
+	for chunk != "" {
+		end := strings.IndexByte(chunk, '\n')
+		if end < 0 {
+			r.line.WriteString(chunk)
+			return nil
+		}
+		r.line.WriteString(chunk[:end+1])
+		chunk = chunk[end+1:]
+		if err := r.endLine(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush renders what is left, e.g. when the response ended or was stopped,
+// and ends the output with a newline.
+func (r *Renderer) Flush() error {
+	if r.term == nil {
+		if !r.midLine {
+			return nil
+		}
+		r.midLine = false
+		_, err := io.WriteString(r.out, "\n")
+		return err
+	}
+	r.block.WriteString(r.line.String())
+	r.line.Reset()
+	r.fence = ""
+	err := r.render()
+	r.paragraph = false
+	return err
+}
+
+func (r *Renderer) endLine() error {
+	line := r.line.String()
+	r.line.Reset()
+	trimmed := strings.TrimSpace(line)
+
+	switch {
+	case r.fence != "":
+		r.block.WriteString(line)
+		if strings.HasPrefix(trimmed, r.fence) && strings.Trim(trimmed, r.fence[:1]) == "" {
+			r.fence = ""
+			return r.render()
+		}
+		return nil
+	case trimmed == "":
+		err := r.render()
+		r.paragraph = false
+		return err
+	case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+		// Code blocks are rendered on their own, once closed
+		if err := r.render(); err != nil {
+			return err
+		}
+		r.paragraph = false
+		r.fence = trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, trimmed[:1]))]
+	case r.block.Len() == 0 && !startsBlock(line):
+		// A heading or a line of a paragraph is complete on its own
+		r.block.WriteString(line)
+		continues := r.paragraph
+		r.paragraph = !strings.HasPrefix(trimmed, "#")
+		if continues {
+			return r.renderContinued()
+		}
+		return r.render()
+	default:
+		r.paragraph = false
+	}
+	r.block.WriteString(line)
+	return nil
+}
+
+// blockStart matches the first line of a list item, a table row or a quote.
+var blockStart = regexp.MustCompile(`^\s*([-*+]|\d+[.)])(\s|$)|^\s*[|>]`)
+
+// startsBlock reports whether line belongs to a block that is only rendered
+// once it has ended: a list, a table, a quote or indented code.
+func startsBlock(line string) bool {
+	return blockStart.MatchString(line) || strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")
+}
+
+// render writes the pending block without the blank lines glamour pads it
+// with, separated from the previous block by one empty line.
+func (r *Renderer) render() error {
+	return r.write(true)
+}
+
+// renderContinued writes the pending line of a paragraph right below the
+// previous line of that paragraph.
+func (r *Renderer) renderContinued() error {
+	return r.write(false)
+}
+
+func (r *Renderer) write(separate bool) error {
+	text := r.block.String()
+	r.block.Reset()
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+
+	output, err := r.term.Render(text)
+	if err != nil {
+		// Better unformatted than lost
+		output = text
+	}
+	lines := strings.Split(output, "\n")
+	for len(lines) > 0 && strings.TrimSpace(ansi.Strip(lines[0])) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(ansi.Strip(lines[len(lines)-1])) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	if r.rendered && separate {
+		if _, err := io.WriteString(r.out, "\n"); err != nil {
+			return err
+		}
+	}
+	r.rendered = true
+	_, err = io.WriteString(r.out, strings.Join(lines, "\n")+"\n")
+	return err
+}
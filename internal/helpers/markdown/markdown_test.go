@@ -0,0 +1,103 @@
+package markdown
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/glamour"
+)
+
+// newTestRenderer returns a renderer that formats without colors into a
+// buffer, as it would on a terminal.
+func newTestRenderer(t *testing.T) (*Renderer, *bytes.Buffer) {
+	t.Helper()
+	term, err := glamour.NewTermRenderer(glamour.WithStandardStyle("notty"), glamour.WithWordWrap(76))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	return &Renderer{out: &out, term: term}, &out
+}
+
+// shown returns the output without the padding glamour adds to each line.
+func shown(out *bytes.Buffer) string {
+	lines := strings.Split(out.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestRendererChunks(t *testing.T) {
+	// Each step writes a chunk and lists what must be shown after it
+	tests := []struct {
+		name  string
+		steps []struct{ chunk, want string }
+	}{
+		{"split line", []struct{ chunk, want string }{
+			{"Hello **wor", ""},
+			{"ld**, how", ""},
+			{" are you?\nFine", "Hello **world**, how are you?\n"},
+			{"\n", "Hello **world**, how are you?\nFine\n"},
+		}},
+		{"heading", []struct{ chunk, want string }{
+			{"# Ti", ""},
+			{"tle\nText\n", "# Title\n\nText\n"},
+		}},
+		{"list until blank line", []struct{ chunk, want string }{
+			{"Steps:\n- one\n", "Steps:\n"},
+			{"- two\n", "Steps:\n"},
+			{"\n", "Steps:\n\n• one\n• two\n"},
+		}},
+		{"fence across chunks", []struct{ chunk, want string }{
+			{"Run:\n``", "Run:\n"},
+			{"`sh\nmake\n\nmake install\n`", "Run:\n"},
+			{"``\nDone\n", "Run:\n\nmake\n\nmake install\n\nDone\n"},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, out := newTestRenderer(t)
+			for _, step := range test.steps {
+				if err := r.Write(step.chunk); err != nil {
+					t.Fatal(err)
+				}
+				if got := shown(out); got != step.want {
+					t.Errorf("after %q the output is %q, want %q", step.chunk, got, step.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRendererFlush(t *testing.T) {
+	r, out := newTestRenderer(t)
+	for _, chunk := range []string{"Look:\n", "```\nunclosed", " code"} {
+		if err := r.Write(chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := shown(out); got != "Look:\n" {
+		t.Errorf("output before Flush is %q, want only the paragraph", got)
+	}
+	if err := r.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := shown(out); got != "Look:\n\nunclosed code\n" {
+		t.Errorf("output after Flush is %q, want the open code block too", got)
+	}
+
+	// A renderer without a terminal writes the text as it arrives and ends
+	// it with a newline
+	var plain bytes.Buffer
+	r = &Renderer{out: &plain}
+	r.Write("- a\n- b")
+	if plain.String() != "- a\n- b" {
+		t.Errorf("plain output is %q, want the text as written", plain.String())
+	}
+	r.Flush()
+	if plain.String() != "- a\n- b\n" {
+		t.Errorf("plain output after Flush is %q, want a final newline", plain.String())
+	}
+}
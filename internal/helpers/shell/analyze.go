@@ -0,0 +1,472 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Finding is something the analyzer flagged in a command.
+type Finding struct {
+	Check  string
+	Action Action
+	Reason string
+}
+
+// Blocked reports whether any finding blocks the command.
+func Blocked(findings []Finding) bool {
+	for _, finding := range findings {
+		if finding.Action == ActionBlock {
+			return true
+		}
+	}
+	return false
+}
+
+// shells are the interpreters a download must not be piped into.
+var shells = map[string]bool{"sh": true, "bash": true, "zsh": true, "dash": true, "ksh": true, "fish": true}
+
+// wrappers run the command that follows their options.
+var wrappers = map[string]bool{"sudo": true, "doas": true, "env": true, "nohup": true, "nice": true, "time": true, "command": true, "exec": true, "xargs": true}
+
+type analyzer struct {
+	policy *Policy
+	// cwd is where the command starts and dir where it is after the cd
+	// commands seen so far, "" when a cd went somewhere unknown
+	cwd      string
+	dir      string
+	home     string
+	findings []Finding
+	seen     map[string]bool
+}
+
+// Analyze parses command as a script of the named shell and checks it against
+// the policy. Relative paths are resolved from cwd, following the cd commands
+// before them. Checks turned off in the policy are not reported.
+func Analyze(command, shellName string, policy *Policy, cwd string) []Finding {
+	a := &analyzer{policy: policy, cwd: cwd, dir: cwd, seen: map[string]bool{}}
+	a.home, _ = os.UserHomeDir()
+
+	if !Parsable(shellName) {
+		a.flag(CheckUnparsable, shellName+" commands cannot be checked")
+		return a.findings
+	}
+	file, err := parse(command, shellName)
+	if err != nil {
+		a.flag(CheckUnparsable, "the command could not be parsed: "+err.Error())
+		return a.findings
+	}
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch node := node.(type) {
+		case *syntax.FuncDecl:
+			a.checkFunc(node)
+		case *syntax.BinaryCmd:
+			if node.Op == syntax.Pipe || node.Op == syntax.PipeAll {
+				if downloads(node.X) && shells[firstCommand(node.Y)] {
+					a.flag(CheckPipeToShell, "pipes a download into a shell")
+				}
+			}
+		case *syntax.Stmt:
+			a.checkRedirects(node.Redirs)
+		case *syntax.CallExpr:
+			a.checkCall(node)
+		}
+		return true
+	})
+	return a.findings
+}
+
+// dialects are the parser variants of the shells whose commands can be
+// checked. zsh is read as bash, which covers the syntax commands use.
+var dialects = map[string]syntax.LangVariant{
+	"bash": syntax.LangBash,
+	"zsh":  syntax.LangBash,
+	"sh":   syntax.LangPOSIX,
+	"dash": syntax.LangPOSIX,
+	"ksh":  syntax.LangMirBSDKorn,
+	"mksh": syntax.LangMirBSDKorn,
+}
+
+// Parsable reports whether commands written for the named shell can be
+// parsed, and so checked. fish, nu, pwsh, csh and tcsh have a syntax of
+// their own.
+func Parsable(shellName string) bool {
+	_, ok := dialects[shellName]
+	return ok
+}
+
+// parse parses command as a script of the named shell.
+func parse(command, shellName string) (*syntax.File, error) {
+	lang, ok := dialects[shellName]
+	if !ok {
+		return nil, fmt.Errorf("%s commands cannot be parsed", shellName)
+	}
+	return syntax.NewParser(syntax.Variant(lang)).Parse(strings.NewReader(command), "")
+}
+
+// flag records a finding once per check and reason, with the action the
+// policy gives the check.
+func (a *analyzer) flag(check, reason string) {
+	action := a.policy.Checks[check]
+	a.add(Finding{Check: check, Action: action, Reason: reason})
+}
+
+func (a *analyzer) add(finding Finding) {
+	if finding.Action == ActionOff || finding.Action == "" {
+		return
+	}
+	key := finding.Check + "\x00" + finding.Reason
+	if a.seen[key] {
+		return
+	}
+	a.seen[key] = true
+	a.findings = append(a.findings, finding)
+}
+
+func (a *analyzer) checkFunc(decl *syntax.FuncDecl) {
+	if callsItself(decl) {
+		a.flag(CheckForkBomb, "function "+decl.Name.Value+" calls itself, like a fork bomb")
+	}
+}
+
+// callsItself reports whether a function is recursive, as fork bombs are.
+func callsItself(decl *syntax.FuncDecl) bool {
+	recursive := false
+	syntax.Walk(decl.Body, func(node syntax.Node) bool {
+		if call, ok := node.(*syntax.CallExpr); ok && len(call.Args) > 0 && call.Args[0].Lit() == decl.Name.Value {
+			recursive = true
+		}
+		return !recursive
+	})
+	return recursive
+}
+
+func (a *analyzer) checkRedirects(redirects []*syntax.Redirect) {
+	for _, redirect := range redirects {
+		switch redirect.Op {
+		case syntax.RdrOut, syntax.AppOut, syntax.ClbOut, syntax.RdrAll, syntax.AppAll:
+			target := wordString(redirect.Word)
+			if a.protected(target) {
+				a.flag(CheckProtectedWrite, "redirects output into "+target)
+			}
+			if isBlockDevice(target) {
+				a.flag(CheckDiskWrite, "writes to the block device "+target)
+			}
+		}
+	}
+}
+
+func (a *analyzer) checkCall(call *syntax.CallExpr) {
+	args := make([]string, len(call.Args))
+	for i, word := range call.Args {
+		args[i] = wordString(word)
+	}
+	args, privileged := unwrap(args)
+	if privileged {
+		a.flag(CheckSudo, "runs with root privileges")
+	}
+	if len(args) == 0 {
+		return
+	}
+
+	name := filepath.Base(args[0])
+	for _, rule := range a.policy.Rules {
+		if rule.Command != name {
+			continue
+		}
+		if rule.args != nil && !rule.args.MatchString(strings.Join(args[1:], " ")) {
+			continue
+		}
+		reason := rule.Reason
+		if reason == "" {
+			reason = "matches the rule " + rule.Name
+		}
+		a.add(Finding{Check: rule.Name, Action: rule.Action, Reason: reason})
+	}
+
+	operands, flags := splitFlags(args[1:])
+	switch {
+	case name == "cd" || name == "pushd":
+		a.changeDir(operands)
+	case name == "rm" && (flags["r"] || flags["R"] || flags["recursive"]):
+		for _, operand := range operands {
+			if a.outsideCwd(operand) {
+				a.flag(CheckRecursiveDelete, "deletes "+operand+" recursively, outside the working directory")
+			}
+		}
+	case name == "find":
+		if findDeletes(args[1:]) {
+			for _, root := range findRoots(args[1:]) {
+				// Unlike rm -r, find . only deletes what is inside the directory
+				if a.outsideCwd(root) && (a.dir == "" || a.resolve(root) != a.cwd) {
+					a.flag(CheckRecursiveDelete, "deletes files under "+root+" with find, outside the working directory")
+				}
+			}
+		}
+	case name == "dd":
+		for _, operand := range operands {
+			if strings.HasPrefix(operand, "of=") {
+				a.flag(CheckDiskWrite, "writes raw data with dd "+operand)
+			}
+		}
+	case strings.HasPrefix(name, "mkfs") || name == "fdisk" || name == "sfdisk" || name == "parted" || name == "wipefs":
+		a.flag(CheckDiskWrite, name+" formats or repartitions a disk")
+	case name == "chmod" && len(operands) > 0 && worldWritable(operands[0]):
+		a.flag(CheckWorldWritable, "makes files writable by everyone with chmod "+operands[0])
+	case shells[name]:
+		// bash <(curl ...) and sh -c "$(wget ...)"
+		for _, word := range call.Args[1:] {
+			if downloads(word) {
+				a.flag(CheckPipeToShell, "runs a download with "+name)
+			}
+		}
+	}
+
+	for _, target := range writeTargets(name, operands, flags) {
+		if a.protected(target) {
+			a.flag(CheckProtectedWrite, name+" writes to "+target)
+		}
+	}
+}
+
+// unwrap drops wrappers such as sudo and env from the front of a command. It
+// reports whether one of them runs the command as root.
+func unwrap(args []string) ([]string, bool) {
+	privileged := false
+	for len(args) > 0 && wrappers[filepath.Base(args[0])] {
+		wrapper := filepath.Base(args[0])
+		if wrapper == "sudo" || wrapper == "doas" {
+			privileged = true
+		}
+		args = args[1:]
+		for len(args) > 0 && (strings.HasPrefix(args[0], "-") || (wrapper == "env" && strings.Contains(args[0], "="))) {
+			takesValue := (wrapper == "sudo" || wrapper == "doas") && (args[0] == "-u" || args[0] == "-g")
+			takesValue = takesValue || (wrapper == "nice" && args[0] == "-n") || (wrapper == "env" && args[0] == "-u")
+			takesValue = takesValue || (wrapper == "xargs" && len(args[0]) == 2 && strings.ContainsAny(args[0][1:], "InPLdsEa"))
+			args = args[1:]
+			if takesValue && len(args) > 0 {
+				args = args[1:]
+			}
+		}
+	}
+	return args, privileged
+}
+
+// changeDir follows a cd to its operand. A cd to a variable or to the
+// previous directory leaves the analyzer without a known directory.
+func (a *analyzer) changeDir(operands []string) {
+	switch {
+	case len(operands) == 0:
+		a.dir = a.home
+	case operands[0] == "-" || strings.Contains(operands[0], "$"):
+		a.dir = ""
+	default:
+		a.dir = a.resolve(operands[0])
+	}
+}
+
+// findRoots returns the paths find searches, which come before the first
+// expression. find searches "." without them.
+func findRoots(args []string) []string {
+	var roots []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") || arg == "(" || arg == "!" {
+			break
+		}
+		roots = append(roots, arg)
+	}
+	if len(roots) == 0 {
+		return []string{"."}
+	}
+	return roots
+}
+
+// findDeletes reports whether a find expression deletes what it finds, with
+// -delete or by running rm on it.
+func findDeletes(args []string) bool {
+	for i, arg := range args {
+		switch arg {
+		case "-delete":
+			return true
+		case "-exec", "-execdir", "-ok", "-okdir":
+			if i+1 < len(args) {
+				if command, _ := unwrap(args[i+1:]); len(command) > 0 && filepath.Base(command[0]) == "rm" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// isBlockDevice reports whether a path is a disk or partition device.
+func isBlockDevice(path string) bool {
+	for _, prefix := range []string{"/dev/sd", "/dev/nvme", "/dev/disk", "/dev/hd", "/dev/mmcblk", "/dev/vd"} {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// outsideCwd reports whether a path reaches outside the working directory.
+// Paths built from variables or substitutions cannot be resolved and count as
+// outside.
+func (a *analyzer) outsideCwd(path string) bool {
+	if strings.Contains(path, "$") || (a.dir == "" && !filepath.IsAbs(path) && !strings.HasPrefix(path, "~")) {
+		return true
+	}
+	resolved := a.resolve(path)
+	rel, err := filepath.Rel(a.cwd, resolved)
+	return err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func (a *analyzer) protected(path string) bool {
+	if path == "" || strings.HasPrefix(path, "/dev/null") || path == "/dev/stdout" || path == "/dev/stderr" {
+		return false
+	}
+	resolved := a.resolve(path)
+	for _, protected := range a.policy.ProtectedPaths {
+		if resolved == protected || strings.HasPrefix(resolved, strings.TrimSuffix(protected, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *analyzer) resolve(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		path = a.home + path[1:]
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(a.dir, path)
+	}
+	return filepath.Clean(path)
+}
+
+// writeTargets returns the operands a command writes to or removes.
+func writeTargets(name string, operands []string, flags map[string]bool) []string {
+	switch name {
+	case "cp", "mv", "install", "ln", "rsync":
+		if len(operands) > 1 {
+			return operands[len(operands)-1:]
+		}
+	case "tee", "touch", "truncate", "mkdir", "rm", "rmdir", "unlink", "shred", "chmod", "chown", "chgrp":
+		if name == "chmod" || name == "chown" || name == "chgrp" {
+			// The first operand is the mode or owner
+			if len(operands) > 0 {
+				return operands[1:]
+			}
+			return nil
+		}
+		return operands
+	case "sed", "perl":
+		if flags["i"] || flags["in-place"] {
+			return operands
+		}
+	}
+	return nil
+}
+
+// splitFlags separates operands from options. Combined short options such as
+// -rf count as each of their letters.
+func splitFlags(args []string) ([]string, map[string]bool) {
+	var operands []string
+	flags := map[string]bool{}
+	for i, arg := range args {
+		switch {
+		case arg == "--":
+			return append(operands, args[i+1:]...), flags
+		case strings.HasPrefix(arg, "--"):
+			name, _, _ := strings.Cut(arg[2:], "=")
+			flags[name] = true
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			for _, letter := range arg[1:] {
+				flags[string(letter)] = true
+			}
+		default:
+			operands = append(operands, arg)
+		}
+	}
+	return operands, flags
+}
+
+func worldWritable(mode string) bool {
+	if strings.HasSuffix(mode, "777") || strings.HasSuffix(mode, "666") {
+		return true
+	}
+	for _, clause := range strings.Split(mode, ",") {
+		who, perms, found := strings.Cut(clause, "+")
+		if !found {
+			who, perms, found = strings.Cut(clause, "=")
+		}
+		if found && (who == "" || strings.ContainsAny(who, "ao")) && strings.Contains(perms, "w") {
+			return true
+		}
+	}
+	return false
+}
+
+// downloads reports whether a node runs curl or wget anywhere inside it.
+func downloads(node syntax.Node) bool {
+	found := false
+	syntax.Walk(node, func(node syntax.Node) bool {
+		if call, ok := node.(*syntax.CallExpr); ok && len(call.Args) > 0 {
+			switch filepath.Base(call.Args[0].Lit()) {
+			case "curl", "wget", "fetch":
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// firstCommand returns the name of the command a statement starts with.
+func firstCommand(stmt *syntax.Stmt) string {
+	call, ok := stmt.Cmd.(*syntax.CallExpr)
+	if !ok || len(call.Args) == 0 {
+		return ""
+	}
+	args := make([]string, len(call.Args))
+	for i, word := range call.Args {
+		args[i] = wordString(word)
+	}
+	args, _ = unwrap(args)
+	if len(args) == 0 {
+		return ""
+	}
+	return filepath.Base(args[0])
+}
+
+// wordString returns a word with its quotes removed. Expansions are kept as
+// written, so callers can tell they were not literal.
+func wordString(word *syntax.Word) string {
+	if word == nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, part := range word.Parts {
+		writeWordPart(&sb, part)
+	}
+	return sb.String()
+}
+
+func writeWordPart(sb *strings.Builder, part syntax.WordPart) {
+	switch part := part.(type) {
+	case *syntax.Lit:
+		sb.WriteString(part.Value)
+	case *syntax.SglQuoted:
+		sb.WriteString(part.Value)
+	case *syntax.DblQuoted:
+		for _, inner := range part.Parts {
+			writeWordPart(sb, inner)
+		}
+	default:
+		syntax.NewPrinter().Print(sb, part)
+	}
+}
@@ -0,0 +1,133 @@
+package shell
+
+import (
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		command string
+		// check is the check expected to fire, "" for none
+		check  string
+		action Action
+	}{
+		{"ls -la", "", ""},
+		{"rm -rf build", "", ""},
+		{"rm -rf /usr", CheckRecursiveDelete, ActionBlock},
+		{"/bin/rm -rf /usr", CheckRecursiveDelete, ActionBlock},
+		{"command rm -rf /", CheckRecursiveDelete, ActionBlock},
+		{"rm -r ../other", CheckRecursiveDelete, ActionBlock},
+		{"rm -rf $DIR", CheckRecursiveDelete, ActionBlock},
+		{"sudo apt update", CheckSudo, ActionWarn},
+		{"dd if=/dev/zero of=/dev/sda", CheckDiskWrite, ActionBlock},
+		{"echo x > /dev/nvme0n1", CheckDiskWrite, ActionBlock},
+		{"mkfs.ext4 /dev/sdb1", CheckDiskWrite, ActionBlock},
+		{"chmod -R a+w .", CheckWorldWritable, ActionBlock},
+		{"chmod 777 run.sh", CheckWorldWritable, ActionBlock},
+		{"curl -fsSL https://example.com/x | sh", CheckPipeToShell, ActionBlock},
+		{"wget -qO- https://example.com/x | sudo -u root bash", CheckPipeToShell, ActionBlock},
+		{`sh -c "$(curl -fsSL https://example.com/x)"`, CheckPipeToShell, ActionBlock},
+		{"echo 127.0.0.1 x >> /etc/hosts", CheckProtectedWrite, ActionBlock},
+		{"cp build/app /usr/local/bin/app", CheckProtectedWrite, ActionBlock},
+		{"sed -i s/a/b/ /etc/fstab", CheckProtectedWrite, ActionBlock},
+		{":(){ :|:& };:", CheckForkBomb, ActionBlock},
+		{"echo 'unterminated", CheckUnparsable, ActionBlock},
+		{"cd / && rm -rf *", CheckRecursiveDelete, ActionBlock},
+		{"cd .. && rm -rf project", CheckRecursiveDelete, ActionBlock},
+		{"cd ..; rm -r project", CheckRecursiveDelete, ActionBlock},
+		{"cd $OLDPWD && rm -rf build", CheckRecursiveDelete, ActionBlock},
+		{"cd build && rm -rf *", "", ""},
+		{"cd /etc && echo x > hosts", CheckProtectedWrite, ActionBlock},
+		{"find / -name '*.log' -delete", CheckRecursiveDelete, ActionBlock},
+		{"find .. -type d -exec rm -rf {} +", CheckRecursiveDelete, ActionBlock},
+		{"find ~/Downloads -execdir sudo rm {} ;", CheckRecursiveDelete, ActionBlock},
+		{"find . -name '*.o' -delete", "", ""},
+		{"find / -name '*.log' -print", "", ""},
+	}
+	cwd := t.TempDir()
+	for _, test := range tests {
+		t.Run(test.command, func(t *testing.T) {
+			findings := Analyze(test.command, "bash", DefaultPolicy(), cwd)
+			if test.check == "" {
+				if len(findings) > 0 {
+					t.Errorf("findings are %v, want none", findings)
+				}
+				return
+			}
+			for _, finding := range findings {
+				if finding.Check == test.check {
+					if finding.Action != test.action {
+						t.Errorf("%s is %s, want %s", test.check, finding.Action, test.action)
+					}
+					return
+				}
+			}
+			t.Errorf("findings are %v, want %s", findings, test.check)
+		})
+	}
+}
+
+func TestAnalyzeRules(t *testing.T) {
+	policy, err := ParsePolicy("shell_policy.yaml", []byte(`
+checks:
+  sudo: off
+rules:
+  - name: docker-prune
+    command: docker
+    args: system prune
+    action: warn
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cwd := t.TempDir()
+
+	if findings := Analyze("sudo ls", "bash", policy, cwd); len(findings) > 0 {
+		t.Errorf("sudo is off, but got %v", findings)
+	}
+	findings := Analyze("docker system prune -af", "bash", policy, cwd)
+	if len(findings) != 1 || findings[0].Check != "docker-prune" || findings[0].Action != ActionWarn {
+		t.Errorf("findings are %v, want a docker-prune warning", findings)
+	}
+	if findings := Analyze("docker ps", "bash", policy, cwd); len(findings) > 0 {
+		t.Errorf("findings are %v, want none", findings)
+	}
+}
+
+func TestParsePolicyErrors(t *testing.T) {
+	for _, data := range []string{
+		"checks:\n  no-such-check: block\n",
+		"checks:\n  sudo: maybe\n",
+		"rules:\n  - name: x\n    action: warn\n",
+		"rules:\n  - command: x\n    args: '('\n    action: warn\n",
+		"unknown_field: 1\n",
+	} {
+		if _, err := ParsePolicy("shell_policy.yaml", []byte(data)); err == nil {
+			t.Errorf("ParsePolicy(%q) succeeded, want an error", data)
+		}
+	}
+}
+
+func TestAnalyzeDialects(t *testing.T) {
+	cwd := t.TempDir()
+	tests := []struct {
+		command    string
+		shell      string
+		unparsable bool
+	}{
+		{"files=(*.go) && echo ${files[0]}", "bash", false},
+		{"files=(*.go) && echo ${files[0]}", "zsh", false},
+		{"files=(*.go) && echo ${files[0]}", "sh", true},
+		{"[ -f go.mod ] && echo yes", "dash", false},
+		{"for f in *.txt; do echo $f; done", "ksh", false},
+		{"for f in *.txt; echo $f; end", "fish", true},
+		{"ls | where size > 1kb", "nu", true},
+	}
+	for _, test := range tests {
+		findings := Analyze(test.command, test.shell, DefaultPolicy(), cwd)
+		unparsable := len(findings) == 1 && findings[0].Check == CheckUnparsable
+		if unparsable != test.unparsable {
+			t.Errorf("Analyze(%q, %s) = %v, want unparsable %v", test.command, test.shell, findings, test.unparsable)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package shell
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// knownShells are the shells genie can run commands with. All of them take
+// the command after -c.
+var knownShells = []string{"bash", "zsh", "fish", "sh", "dash", "ksh", "mksh", "tcsh", "csh", "nu", "pwsh"}
+
+// packageManagers are looked up on PATH in this order, so the native manager
+// of a system wins over ones installed next to it.
+var packageManagers = map[string][]string{
+	"linux":   {"apt", "dnf", "yum", "pacman", "zypper", "apk", "emerge", "xbps-install", "nix-env", "brew"},
+	"darwin":  {"brew", "port", "nix-env"},
+	"freebsd": {"pkg"},
+	"windows": {"winget", "choco", "scoop"},
+}
+
+// commonTools are the tools the model should know it can, or cannot, use.
+var commonTools = []string{"rg", "grep", "fd", "find", "gsed", "sed", "gawk", "awk", "jq", "tar", "zip", "gzip", "xargs", "git", "docker", "kubectl", "python3", "node"}
+
+// Environment describes where the commands genie proposes will run.
+type Environment struct {
+	OS string
+	// Distro is the name of the Linux distribution or macOS, if known.
+	Distro string
+	// Shell is the name of the user's shell, e.g. zsh, and ShellPath the
+	// program commands are run with.
+	Shell     string
+	ShellPath string
+	// ShellSource says how the shell was found: the parent process, $SHELL
+	// or the default.
+	ShellSource    string
+	PackageManager string
+	Tools          []string
+	MissingTools   []string
+}
+
+// DetectEnvironment looks at the user's shell, operating system, package
+// manager and tools. The shell is the one genie was started from when that
+// is a known shell, otherwise $SHELL, otherwise sh.
+func DetectEnvironment() Environment {
+	env := Environment{OS: runtime.GOOS, Distro: distro()}
+
+	if name := parentShell(); name != "" {
+		env.Shell, env.ShellSource = name, "parent process"
+	} else if name := shellName(os.Getenv("SHELL")); name != "" {
+		env.Shell, env.ShellSource = name, "$SHELL"
+	} else {
+		env.Shell, env.ShellSource = "sh", "default"
+	}
+	env.ShellPath = env.Shell
+	if path, err := exec.LookPath(env.Shell); err == nil {
+		env.ShellPath = path
+	} else {
+		env.Shell, env.ShellPath, env.ShellSource = "sh", "sh", "default"
+	}
+
+	for _, manager := range packageManagers[runtime.GOOS] {
+		if _, err := exec.LookPath(manager); err == nil {
+			env.PackageManager = manager
+			break
+		}
+	}
+	for _, tool := range commonTools {
+		if _, err := exec.LookPath(tool); err == nil {
+			env.Tools = append(env.Tools, tool)
+		} else {
+			env.MissingTools = append(env.MissingTools, tool)
+		}
+	}
+	return env
+}
+
+// WithShell returns the environment with commands run by the named shell
+// instead of the user's, e.g. sh when the user's shell cannot be checked.
+// source says why, like ShellSource.
+func (e Environment) WithShell(name, source string) Environment {
+	e.Shell, e.ShellPath, e.ShellSource = name, name, source
+	if path, err := exec.LookPath(name); err == nil {
+		e.ShellPath = path
+	}
+	return e
+}
+
+// Describe returns the environment as it is given to the model.
+func (e Environment) Describe() string {
+	var sb strings.Builder
+	system := e.OS
+	if e.Distro != "" {
+		system += " (" + e.Distro + ")"
+	}
+	fmt.Fprintf(&sb, "Operating system: %s\n", system)
+	fmt.Fprintf(&sb, "Shell: %s, commands are run with %s -c, so use its syntax\n", e.Shell, e.ShellPath)
+	if e.PackageManager != "" {
+		fmt.Fprintf(&sb, "Package manager: %s\n", e.PackageManager)
+	}
+	if len(e.Tools) > 0 {
+		fmt.Fprintf(&sb, "Installed tools: %s\n", strings.Join(e.Tools, ", "))
+	}
+	if len(e.MissingTools) > 0 {
+		fmt.Fprintf(&sb, "Not installed: %s\n", strings.Join(e.MissingTools, ", "))
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// shellName returns the name of a known shell from a path such as /bin/zsh
+// or a login shell name such as -zsh.
+func shellName(path string) string {
+	name := strings.TrimPrefix(filepath.Base(strings.TrimSpace(path)), "-")
+	for _, shell := range knownShells {
+		if name == shell {
+			return shell
+		}
+	}
+	return ""
+}
+
+// parentShell returns the shell genie was started from, if its parent
+// process is one.
+func parentShell() string {
+	ppid := os.Getppid()
+	switch runtime.GOOS {
+	case "linux":
+		data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(ppid), "comm"))
+		if err != nil {
+			return ""
+		}
+		return shellName(string(data))
+	case "darwin", "freebsd", "openbsd", "netbsd":
+		out, err := exec.Command("ps", "-o", "comm=", "-p", strconv.Itoa(ppid)).Output()
+		if err != nil {
+			return ""
+		}
+		return shellName(string(out))
+	}
+	return ""
+}
+
+// distro names the Linux distribution from /etc/os-release, or macOS.
+func distro() string {
+	switch runtime.GOOS {
+	case "darwin":
+		if out, err := exec.Command("sw_vers", "-productVersion").Output(); err == nil {
+			return "macOS " + strings.TrimSpace(string(out))
+		}
+		return "macOS"
+	case "linux":
+		file, err := os.Open("/etc/os-release")
+		if err != nil {
+			return ""
+		}
+		defer file.Close()
+
+		values := map[string]string{}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if key, value, found := strings.Cut(scanner.Text(), "="); found {
+				values[key] = strings.Trim(value, `"'`)
+			}
+		}
+		if name := values["PRETTY_NAME"]; name != "" {
+			return name
+		}
+		return strings.TrimSpace(values["NAME"] + " " + values["VERSION_ID"])
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+package shell
+
+import (
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Token is a word of a command stage, e.g. a flag or an argument.
+type Token struct {
+	Text string
+	// Kind is "command", "flag", "argument", "assignment", "redirect",
+	// "background" or "compound" for loops, conditions and subshells.
+	Kind string
+}
+
+// Stage is one command of a statement. Operator joins it to the next stage:
+// |, &&, || or ;.
+type Stage struct {
+	Text     string
+	Tokens   []Token
+	Operator string
+}
+
+// Statement is what is written on one line of a script, split into stages.
+type Statement struct {
+	Line   int
+	Text   string
+	Stages []Stage
+}
+
+// Breakdown parses a command line or a script of the named shell into
+// statements, one per line, with their pipeline stages and words.
+func Breakdown(script, shellName string) ([]Statement, error) {
+	file, err := parse(script, shellName)
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []Statement
+	for _, stmt := range file.Stmts {
+		line := int(stmt.Pos().Line())
+		stages := breakStmt(stmt)
+
+		// Commands separated by ; on one line stay one statement
+		if n := len(statements); n > 0 && statements[n-1].Line == line {
+			last := &statements[n-1]
+			last.Stages[len(last.Stages)-1].Operator = ";"
+			last.Stages = append(last.Stages, stages...)
+			last.Text += "; " + printNode(stmt)
+			continue
+		}
+		statements = append(statements, Statement{Line: line, Text: printNode(stmt), Stages: stages})
+	}
+	return statements, nil
+}
+
+func breakStmt(stmt *syntax.Stmt) []Stage {
+	if binary, ok := stmt.Cmd.(*syntax.BinaryCmd); ok && len(stmt.Redirs) == 0 && !stmt.Background && !stmt.Negated {
+		stages := breakStmt(binary.X)
+		stages[len(stages)-1].Operator = binary.Op.String()
+		return append(stages, breakStmt(binary.Y)...)
+	}
+
+	stage := Stage{Text: printNode(stmt)}
+	switch command := stmt.Cmd.(type) {
+	case *syntax.CallExpr:
+		for _, assign := range command.Assigns {
+			stage.Tokens = append(stage.Tokens, Token{Text: printNode(assign), Kind: "assignment"})
+		}
+		for i, word := range command.Args {
+			kind := "argument"
+			text := printNode(word)
+			switch {
+			case i == 0:
+				kind = "command"
+			case strings.HasPrefix(text, "-") && text != "-":
+				kind = "flag"
+			}
+			stage.Tokens = append(stage.Tokens, Token{Text: text, Kind: kind})
+		}
+	case nil:
+	default:
+		stage.Tokens = append(stage.Tokens, Token{Text: printNode(command), Kind: "compound"})
+	}
+	for _, redirect := range stmt.Redirs {
+		text := redirect.Op.String() + printNode(redirect.Word)
+		if redirect.N != nil {
+			text = redirect.N.Value + text
+		}
+		stage.Tokens = append(stage.Tokens, Token{Text: text, Kind: "redirect"})
+	}
+	if stmt.Background {
+		stage.Tokens = append(stage.Tokens, Token{Text: "&", Kind: "background"})
+	}
+	return []Stage{stage}
+}
+
+// printNode prints a node back as shell code, on one line where the syntax
+// allows it.
+func printNode(node syntax.Node) string {
+	var sb strings.Builder
+	syntax.NewPrinter(syntax.SingleLine(true)).Print(&sb, node)
+	return strings.TrimSpace(sb.String())
+}
@@ -0,0 +1,62 @@
+package shell
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// describe writes statements compactly: statements by line, stages joined by
+// their operators, and each word as kind:text.
+func describe(statements []Statement) string {
+	var parts []string
+	for _, statement := range statements {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%d:", statement.Line)
+		for _, stage := range statement.Stages {
+			for _, token := range stage.Tokens {
+				fmt.Fprintf(&sb, " %s:%s", token.Kind, token.Text)
+			}
+			if stage.Operator != "" {
+				sb.WriteString(" " + stage.Operator)
+			}
+		}
+		parts = append(parts, sb.String())
+	}
+	return strings.Join(parts, "\n")
+}
+
+func TestBreakdown(t *testing.T) {
+	tests := []struct {
+		script string
+		want   string
+	}{
+		{"ls -la /tmp", "1: command:ls flag:-la argument:/tmp"},
+		{"cat log | grep -v debug | wc -l", "1: command:cat argument:log | command:grep flag:-v argument:debug | command:wc flag:-l"},
+		{"make && make install || echo failed", "1: command:make && command:make argument:install || command:echo argument:failed"},
+		{"cd build; make; cd ..", "1: command:cd argument:build ; command:make ; command:cd argument:.."},
+		{"make 2>&1 >build.log", "1: command:make redirect:2>&1 redirect:>build.log"},
+		{"sort <in.txt 2>/dev/null >>out.txt", "1: command:sort redirect:<in.txt redirect:2>/dev/null redirect:>>out.txt"},
+		{"CGO_ENABLED=0 GOOS=linux go build", "1: assignment:CGO_ENABLED=0 assignment:GOOS=linux command:go argument:build"},
+		{"sleep 10 &", "1: command:sleep argument:10 background:&"},
+		{"for f in *.log; do gzip $f; done", "1: compound:for f in *.log; do gzip $f; done"},
+		{"if [ -d build ]; then rm -r build; fi > /dev/null", "1: compound:if [ -d build ]; then rm -r build; fi redirect:>/dev/null"},
+		{"(cd /tmp && ls)", "1: compound:(cd /tmp && ls)"},
+		{"echo - done", "1: command:echo argument:- argument:done"},
+		{"set -e\n\n# build\ngo build ./...\ngo test ./...", "1: command:set flag:-e\n4: command:go argument:build argument:./...\n5: command:go argument:test argument:./..."},
+	}
+	for _, test := range tests {
+		statements, err := Breakdown(test.script, "bash")
+		if err != nil {
+			t.Errorf("Breakdown(%q) failed: %v", test.script, err)
+			continue
+		}
+		if got := describe(statements); got != test.want {
+			t.Errorf("Breakdown(%q) is\n%s\nwant\n%s", test.script, got, test.want)
+		}
+	}
+
+	if _, err := Breakdown("if then fi (", "bash"); err == nil {
+		t.Error("Breakdown of an invalid script succeeded, want an error")
+	}
+}
@@ -0,0 +1,180 @@
+package shell
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+
+	"github.com/harshalranjhani/genie/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// PolicyFileName is the policy genie do --safe checks commands against. It is
+// kept in the config directory and written with the defaults on first use.
+const PolicyFileName = "shell_policy.yaml"
+
+// Action is what happens when a check finds something.
+type Action string
+
+const (
+	ActionBlock Action = "block"
+	ActionWarn  Action = "warn"
+	ActionOff   Action = "off"
+)
+
+// The built-in checks of the analyzer.
+const (
+	CheckRecursiveDelete = "recursive-delete-outside-cwd"
+	CheckDiskWrite       = "disk-write"
+	CheckWorldWritable   = "world-writable"
+	CheckPipeToShell     = "pipe-to-shell"
+	CheckProtectedWrite  = "protected-write"
+	CheckForkBomb        = "fork-bomb"
+	CheckSudo            = "sudo"
+	CheckUnparsable      = "unparsable"
+)
+
+// Rule is a check written by the user: it matches a command by name and,
+// optionally, a regular expression on its arguments.
+type Rule struct {
+	Name    string `yaml:"name"`
+	Command string `yaml:"command"`
+	Args    string `yaml:"args,omitempty"`
+	Action  Action `yaml:"action"`
+	Reason  string `yaml:"reason,omitempty"`
+
+	args *regexp.Regexp
+}
+
+// Policy decides what each check does and lists the paths commands may not
+// write to.
+type Policy struct {
+	// Path is the file the policy was read from.
+	Path string `yaml:"-"`
+
+	Checks         map[string]Action `yaml:"checks"`
+	ProtectedPaths []string          `yaml:"protected_paths"`
+	Rules          []Rule            `yaml:"rules"`
+}
+
+// systemPaths are the protected paths of the default policy, the system
+// directories commands should not write to.
+var systemPaths = []string{"/etc", "/boot", "/bin", "/sbin", "/lib", "/lib64", "/usr", "/var", "/dev", "/sys", "/proc", "/System", "/Library"}
+
+// DefaultPolicy blocks every built-in check except sudo, which only warns.
+func DefaultPolicy() *Policy {
+	return &Policy{
+		Checks: map[string]Action{
+			CheckRecursiveDelete: ActionBlock,
+			CheckDiskWrite:       ActionBlock,
+			CheckWorldWritable:   ActionBlock,
+			CheckPipeToShell:     ActionBlock,
+			CheckProtectedWrite:  ActionBlock,
+			CheckForkBomb:        ActionBlock,
+			CheckSudo:            ActionWarn,
+			CheckUnparsable:      ActionBlock,
+		},
+		ProtectedPaths: slices.Clone(systemPaths),
+	}
+}
+
+const defaultPolicyFile = `# Shell policy of genie do --safe.
+# Each check is block, warn or off.
+checks:
+  recursive-delete-outside-cwd: block  # rm -r on paths outside the working directory
+  disk-write: block                    # dd of=..., mkfs, fdisk, wipefs, parted
+  world-writable: block                # chmod 777, chmod -R a+w
+  pipe-to-shell: block                 # curl ... | sh, bash <(wget ...)
+  protected-write: block               # writes under protected_paths
+  fork-bomb: block                     # functions that call themselves
+  sudo: warn
+  unparsable: block                    # commands the shell parser cannot read
+
+# Paths commands may not write to or delete from.
+protected_paths: [/etc, /boot, /bin, /sbin, /lib, /lib64, /usr, /var, /dev, /sys, /proc, /System, /Library]
+
+# Rules of your own. args is a regular expression on the arguments.
+# rules:
+#   - name: docker-prune
+#     command: docker
+#     args: system prune
+#     action: warn
+#     reason: removes all unused Docker data
+`
+
+// LoadPolicy reads the policy from the config directory, writing the default
+// one there when it does not exist yet. Checks the file leaves out keep their
+// default action.
+func LoadPolicy() (*Policy, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(configDir, PolicyFileName)
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		data = []byte(defaultPolicyFile)
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	return ParsePolicy(path, data)
+}
+
+// ParsePolicy parses a policy file on top of the default policy.
+func ParsePolicy(path string, data []byte) (*Policy, error) {
+	var read Policy
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&read); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+
+	policy := DefaultPolicy()
+	policy.Path = path
+	for check, action := range read.Checks {
+		if _, known := policy.Checks[check]; !known {
+			return nil, fmt.Errorf("invalid %s: unknown check %q", path, check)
+		}
+		if !action.valid() {
+			return nil, fmt.Errorf("invalid %s: check %s: invalid action %q, use block, warn or off", path, check, action)
+		}
+		policy.Checks[check] = action
+	}
+	if read.ProtectedPaths != nil {
+		policy.ProtectedPaths = read.ProtectedPaths
+	}
+
+	for i, rule := range read.Rules {
+		if rule.Command == "" {
+			return nil, fmt.Errorf("invalid %s: rule %d has no command", path, i+1)
+		}
+		if !rule.Action.valid() {
+			return nil, fmt.Errorf("invalid %s: rule %s: invalid action %q, use block, warn or off", path, rule.Name, rule.Action)
+		}
+		if rule.Args != "" {
+			pattern, err := regexp.Compile(rule.Args)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: rule %s: %w", path, rule.Name, err)
+			}
+			rule.args = pattern
+		}
+		if rule.Name == "" {
+			rule.Name = rule.Command
+		}
+		policy.Rules = append(policy.Rules, rule)
+	}
+	return policy, nil
+}
+
+func (a Action) valid() bool {
+	return a == ActionBlock || a == ActionWarn || a == ActionOff
+}
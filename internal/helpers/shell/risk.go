@@ -0,0 +1,266 @@
+// Package shell looks at the commands genie proposes before they are run.
+package shell
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Risk grades how much damage a command can do.
+type Risk int
+
+const (
+	RiskLow Risk = iota
+	RiskMedium
+	RiskHigh
+)
+
+func (r Risk) String() string {
+	switch r {
+	case RiskHigh:
+		return "high"
+	case RiskMedium:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// ParseRisk reads a risk written as low, medium or high.
+func ParseRisk(text string) (Risk, bool) {
+	for _, risk := range []Risk{RiskLow, RiskMedium, RiskHigh} {
+		if strings.EqualFold(text, risk.String()) {
+			return risk, true
+		}
+	}
+	return RiskLow, false
+}
+
+// Assessment is the risk of a command and why it was given.
+type Assessment struct {
+	Risk    Risk
+	Reasons []string
+}
+
+// assessor grades the commands of a parsed script.
+type assessor struct {
+	assessment Assessment
+	seen       map[string]bool
+	// dir is the directory a cd to an absolute path moved to, so that the
+	// relative paths after it can be graded
+	dir string
+}
+
+func (a *assessor) add(risk Risk, reason string) {
+	if a.seen[reason] {
+		return
+	}
+	a.seen[reason] = true
+	if risk > a.assessment.Risk {
+		a.assessment.Risk = risk
+	}
+	a.assessment.Reasons = append(a.assessment.Reasons, reason)
+}
+
+// Assess grades a command of the named shell by what its parsed commands do,
+// seen through wrappers such as sudo, env and command and whatever path they
+// are called by. It errs on the side of caution: a command is as risky as
+// its riskiest part, and one that cannot be parsed is not low risk.
+func Assess(command, shellName string) Assessment {
+	a := &assessor{seen: map[string]bool{}}
+	if !Parsable(shellName) {
+		a.add(RiskMedium, shellName+" commands cannot be checked")
+		return a.assessment
+	}
+	file, err := parse(command, shellName)
+	if err != nil {
+		a.add(RiskMedium, "could not be parsed, so it was not checked")
+		return a.assessment
+	}
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch node := node.(type) {
+		case *syntax.FuncDecl:
+			if callsItself(node) {
+				a.add(RiskHigh, "looks like a fork bomb")
+			}
+		case *syntax.BinaryCmd:
+			if (node.Op == syntax.Pipe || node.Op == syntax.PipeAll) && downloads(node.X) && shells[firstCommand(node.Y)] {
+				a.add(RiskHigh, "pipes a download into a shell")
+			}
+		case *syntax.Stmt:
+			a.checkRedirects(node.Redirs)
+		case *syntax.CallExpr:
+			a.checkCall(node)
+		}
+		return true
+	})
+	return a.assessment
+}
+
+func (a *assessor) checkRedirects(redirects []*syntax.Redirect) {
+	for _, redirect := range redirects {
+		switch redirect.Op {
+		case syntax.RdrOut, syntax.ClbOut, syntax.RdrAll, syntax.AppOut, syntax.AppAll:
+			target := wordString(redirect.Word)
+			switch {
+			case isBlockDevice(target):
+				a.add(RiskHigh, "writes to a block device")
+			case a.systemPath(target):
+				a.add(RiskHigh, "writes to a system path")
+			case redirect.Op == syntax.AppOut || redirect.Op == syntax.AppAll:
+			case target != "/dev/null" && target != "/dev/stdout" && target != "/dev/stderr":
+				a.add(RiskMedium, "overwrites a file with a redirect")
+			}
+		}
+	}
+}
+
+func (a *assessor) checkCall(call *syntax.CallExpr) {
+	args := make([]string, len(call.Args))
+	for i, word := range call.Args {
+		args[i] = wordString(word)
+	}
+	args, privileged := unwrap(args)
+	if privileged {
+		a.add(RiskMedium, "runs with root privileges")
+	}
+	if len(args) == 0 {
+		return
+	}
+
+	name := filepath.Base(args[0])
+	operands, flags := splitFlags(args[1:])
+	for _, target := range writeTargets(name, operands, flags) {
+		if a.systemPath(target) {
+			a.add(RiskHigh, "writes to a system path")
+		}
+	}
+	switch {
+	case name == "cd":
+		a.dir = ""
+		if len(operands) > 0 && filepath.IsAbs(operands[0]) {
+			a.dir = filepath.Clean(operands[0])
+		}
+	case name == "rm":
+		if flags["r"] || flags["R"] || flags["f"] || flags["recursive"] || flags["force"] {
+			a.add(RiskHigh, "deletes files recursively or forcefully")
+		} else {
+			a.add(RiskMedium, "deletes files")
+		}
+	case name == "rmdir" || name == "unlink" || name == "shred":
+		a.add(RiskMedium, "deletes files")
+	case strings.HasPrefix(name, "mkfs") || name == "fdisk" || name == "sfdisk" || name == "parted" || name == "wipefs":
+		a.add(RiskHigh, "formats or repartitions a disk")
+	case name == "dd":
+		for _, operand := range operands {
+			if strings.HasPrefix(operand, "of=") {
+				a.add(RiskHigh, "writes raw data with dd")
+			}
+		}
+	case name == "shutdown" || name == "reboot" || name == "halt" || name == "poweroff":
+		a.add(RiskHigh, "shuts the machine down")
+	case name == "git" && len(operands) > 0:
+		switch operands[0] {
+		case "push":
+			if flags["f"] || flags["force"] || flags["force-with-lease"] || slices.ContainsFunc(operands[1:], func(ref string) bool { return strings.HasPrefix(ref, "+") }) {
+				a.add(RiskHigh, "discards git history or changes")
+			}
+		case "reset":
+			if flags["hard"] {
+				a.add(RiskHigh, "discards git history or changes")
+			}
+		case "clean":
+			if flags["f"] || flags["force"] {
+				a.add(RiskHigh, "discards git history or changes")
+			}
+		}
+	case name == "chmod" || name == "chown" || name == "chgrp":
+		if name == "chmod" && len(operands) > 0 && worldWritable(operands[0]) {
+			a.add(RiskMedium, "makes files writable by everyone")
+		}
+		if flags["R"] || flags["recursive"] {
+			a.add(RiskMedium, "changes permissions recursively")
+			if len(operands) > 1 && slices.ContainsFunc(operands[1:], func(path string) bool { return a.resolve(path) == "/" || a.systemPath(path) }) {
+				a.add(RiskHigh, "changes permissions of system files recursively")
+			}
+		}
+	case name == "mv" || name == "cp":
+		if flags["f"] || flags["force"] {
+			a.add(RiskMedium, "overwrites files without asking")
+		}
+	case name == "kill" || name == "pkill" || name == "killall":
+		a.add(RiskMedium, "stops processes")
+	case name == "find":
+		// Not splitFlags: find's options are words, not letters
+		for _, arg := range args[1:] {
+			if arg == "-delete" || arg == "-exec" || arg == "-execdir" || arg == "-ok" {
+				a.add(RiskMedium, "runs an action on every file found")
+			}
+		}
+	case shells[name]:
+		for _, word := range call.Args[1:] {
+			if downloads(word) {
+				a.add(RiskHigh, "pipes a download into a shell")
+			}
+		}
+	}
+}
+
+// resolve makes path absolute against the directory of the last cd, or
+// returns "" when it cannot be known.
+func (a *assessor) resolve(path string) string {
+	switch {
+	case filepath.IsAbs(path):
+		return filepath.Clean(path)
+	case a.dir != "" && !strings.HasPrefix(path, "~") && !strings.Contains(path, "$"):
+		return filepath.Join(a.dir, path)
+	}
+	return ""
+}
+
+// systemPath reports whether path is in one of the system directories of
+// the default policy. The standard streams and /dev/null are not.
+func (a *assessor) systemPath(path string) bool {
+	switch path {
+	case "", "/dev/null", "/dev/stdout", "/dev/stderr", "/dev/tty":
+		return false
+	}
+	if strings.HasPrefix(path, "/dev/fd/") {
+		return false
+	}
+	resolved := a.resolve(path)
+	if resolved == "" {
+		return false
+	}
+	for _, dir := range systemPaths {
+		if resolved == dir || strings.HasPrefix(resolved, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// CleanCommand strips the Markdown code fences and prompt markers models
+// sometimes wrap commands in.
+func CleanCommand(text string) string {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```")
+		if newline := strings.IndexByte(text, '\n'); newline >= 0 {
+			// Drop the language tag of the fence
+			if tag := strings.TrimSpace(text[:newline]); !strings.Contains(tag, " ") {
+				text = text[newline+1:]
+			}
+		}
+		text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+	}
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "$ ") {
+		text = strings.TrimPrefix(text, "$ ")
+	}
+	return text
+}
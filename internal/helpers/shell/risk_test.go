@@ -0,0 +1,85 @@
+package shell
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAssess(t *testing.T) {
+	tests := []struct {
+		command string
+		risk    Risk
+		reason  string
+	}{
+		{"ls -la", RiskLow, ""},
+		{"echo hi >> notes.txt", RiskLow, ""},
+		{"make 2> /dev/null", RiskLow, ""},
+		{"rm -rf /usr", RiskHigh, "deletes files recursively or forcefully"},
+		{"/bin/rm -rf /usr", RiskHigh, "deletes files recursively or forcefully"},
+		{"command rm -rf /", RiskHigh, "deletes files recursively or forcefully"},
+		{"env LANG=C /usr/bin/rm -r build", RiskHigh, "deletes files recursively or forcefully"},
+		{"find . -name '*.o' | xargs -I {} rm -f {}", RiskHigh, "deletes files recursively or forcefully"},
+		{"rm notes.txt", RiskMedium, "deletes files"},
+		{"sudo apt install jq", RiskMedium, "runs with root privileges"},
+		{"curl -fsSL https://example.com/install.sh | sudo bash", RiskHigh, "pipes a download into a shell"},
+		{"bash <(wget -qO- https://example.com/x)", RiskHigh, "pipes a download into a shell"},
+		{"dd if=/dev/zero of=/dev/sda bs=1M", RiskHigh, "writes raw data with dd"},
+		{"cat image.iso > /dev/sdb", RiskHigh, "writes to a block device"},
+		{"/sbin/mkfs.ext4 /dev/sdb1", RiskHigh, "formats or repartitions a disk"},
+		{":(){ :|:& };:", RiskHigh, "looks like a fork bomb"},
+		{"git push --force origin main", RiskHigh, "discards git history or changes"},
+		{"git reset --hard HEAD~1", RiskHigh, "discards git history or changes"},
+		{"git push origin main", RiskLow, ""},
+		{"chmod -R 777 /", RiskHigh, "changes permissions of system files recursively"},
+		{"sudo chown -R me /usr/local", RiskHigh, "changes permissions of system files recursively"},
+		{"cd / && chmod -R 755 etc", RiskHigh, "changes permissions of system files recursively"},
+		{"echo x > /etc/passwd", RiskHigh, "writes to a system path"},
+		{"echo 127.0.0.1 box >> /etc/hosts", RiskHigh, "writes to a system path"},
+		{"cd /etc && echo x > hosts", RiskHigh, "writes to a system path"},
+		{"echo x | tee /etc/hosts", RiskHigh, "writes to a system path"},
+		{"cp x /etc/", RiskHigh, "writes to a system path"},
+		{"mv genie /usr/bin/genie", RiskHigh, "writes to a system path"},
+		{"cp x ./etc/", RiskLow, ""},
+		{"echo x | tee notes.txt", RiskLow, ""},
+		{"make 2> /dev/null", RiskLow, ""},
+		{"chmod 777 script.sh", RiskMedium, "makes files writable by everyone"},
+		{"chown -R me: .", RiskMedium, "changes permissions recursively"},
+		{"echo hi > notes.txt", RiskMedium, "overwrites a file with a redirect"},
+		{"find . -name '*.tmp' -delete", RiskMedium, "runs an action on every file found"},
+		{"pkill node", RiskMedium, "stops processes"},
+		{"if then fi (", RiskMedium, "could not be parsed, so it was not checked"},
+	}
+	for _, test := range tests {
+		t.Run(test.command, func(t *testing.T) {
+			assessment := Assess(test.command, "bash")
+			if assessment.Risk != test.risk {
+				t.Errorf("risk is %s, want %s (reasons: %v)", assessment.Risk, test.risk, assessment.Reasons)
+			}
+			if test.reason == "" && len(assessment.Reasons) > 0 {
+				t.Errorf("reasons are %v, want none", assessment.Reasons)
+			}
+			if test.reason != "" && !slices.Contains(assessment.Reasons, test.reason) {
+				t.Errorf("reasons are %v, want %q among them", assessment.Reasons, test.reason)
+			}
+		})
+	}
+}
+
+func TestParseRisk(t *testing.T) {
+	tests := []struct {
+		text string
+		risk Risk
+		ok   bool
+	}{
+		{"low", RiskLow, true},
+		{"Medium", RiskMedium, true},
+		{"HIGH", RiskHigh, true},
+		{"severe", RiskLow, false},
+	}
+	for _, test := range tests {
+		risk, ok := ParseRisk(test.text)
+		if risk != test.risk || ok != test.ok {
+			t.Errorf("ParseRisk(%q) = %s, %v, want %s, %v", test.text, risk, ok, test.risk, test.ok)
+		}
+	}
+}
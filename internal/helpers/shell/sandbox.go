@@ -0,0 +1,416 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// The most a dry run copies into its sandbox. A bigger directory, such as a
+// home directory or a project with its dependencies, is not copied at all.
+const (
+	MaxSandboxFiles       = 20000
+	MaxSandboxBytes int64 = 512 << 20
+)
+
+// ErrSandboxTooLarge is returned by DryRun when the directory, without the
+// skipped paths, is over MaxSandboxFiles or MaxSandboxBytes.
+var ErrSandboxTooLarge = errors.New("the directory is too large to copy into a sandbox")
+
+// Change is what a dry run did to one file of the sandbox.
+type Change struct {
+	Path string
+	// Kind is "created", "modified" or "deleted".
+	Kind       string
+	SizeBefore int64
+	SizeAfter  int64
+}
+
+// DryRunResult is the outcome of running a command in a sandbox.
+type DryRunResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	Changes  []Change
+	// Skipped counts the files left out of the sandbox by the ignore list.
+	Skipped int
+	// SkippedLinks are the symlinks left out because they point outside the
+	// directory, as "path -> target".
+	SkippedLinks []string
+}
+
+type fileState struct {
+	size int64
+	mode fs.FileMode
+	hash [sha256.Size]byte
+}
+
+// DryRun copies dir into a temporary directory, leaving out the paths skip
+// returns true for, runs command there with shellPath and reports what it
+// printed and which files it created, modified or deleted. The copy is
+// removed afterwards.
+//
+// The sandbox only covers the copied directory: absolute paths and network
+// access still reach the real system, see Escapes.
+func DryRun(ctx context.Context, shellPath, command, dir string, skip func(path string) bool) (*DryRunResult, error) {
+	sandbox, err := os.MkdirTemp("", "genie-dry-run-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the sandbox: %w", err)
+	}
+	defer os.RemoveAll(sandbox)
+
+	if err := checkTreeSize(dir, skip); err != nil {
+		return nil, err
+	}
+	result := &DryRunResult{}
+	result.Skipped, result.SkippedLinks, err = copyTree(dir, sandbox, skip)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy %s into the sandbox: %w", dir, err)
+	}
+
+	before, err := snapshot(sandbox)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, shellPath, "-c", command)
+	cmd.Dir = sandbox
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(), "PWD="+sandbox)
+	err = cmd.Run()
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		return nil, fmt.Errorf("failed to run the command: %w", err)
+	}
+	result.Stdout = strings.ReplaceAll(stdout.String(), sandbox, dir)
+	result.Stderr = strings.ReplaceAll(stderr.String(), sandbox, dir)
+
+	after, err := snapshot(sandbox)
+	if err != nil {
+		return nil, err
+	}
+	result.Changes = diffSnapshots(before, after)
+	return result, nil
+}
+
+// checkTreeSize walks src like copyTree and fails with ErrSandboxTooLarge as
+// soon as the files to copy are over the limits.
+func checkTreeSize(src string, skip func(path string) bool) error {
+	files, size := 0, int64(0)
+	err := filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != src && skip != nil && skip(path) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		files++
+		size += info.Size()
+		switch {
+		case files > MaxSandboxFiles:
+			return fmt.Errorf("%w: more than %d files", ErrSandboxTooLarge, MaxSandboxFiles)
+		case size > MaxSandboxBytes:
+			return fmt.Errorf("%w: more than %d MB", ErrSandboxTooLarge, MaxSandboxBytes>>20)
+		}
+		return nil
+	})
+	return err
+}
+
+// copyTree copies the regular files, directories and symlinks of src into
+// dst and returns how many paths were skipped. Symlinks are only copied when
+// they resolve inside src, as relative links; the others would let a dry run
+// write to the real files, so they are left out and returned.
+func copyTree(src, dst string, skip func(path string) bool) (int, []string, error) {
+	root, err := filepath.EvalSymlinks(src)
+	if err != nil {
+		return 0, nil, err
+	}
+	skipped := 0
+	var links []string
+	err = filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if skip != nil && skip(path) {
+			skipped++
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		target := filepath.Join(dst, rel)
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.IsDir():
+			return os.Mkdir(target, info.Mode().Perm()|0700)
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			resolved, ok := linkTarget(root, filepath.Join(root, rel), link)
+			if !ok {
+				links = append(links, rel+" -> "+link)
+				return nil
+			}
+			relLink, err := filepath.Rel(filepath.Dir(filepath.Join(root, rel)), resolved)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(relLink, target)
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		default:
+			// Sockets, devices and pipes are left out
+			skipped++
+			return nil
+		}
+	})
+	return skipped, links, err
+}
+
+// linkTarget resolves the symlink at path, which reads link, and reports
+// whether it ends up inside root. Dangling links are judged by their path.
+func linkTarget(root, path, link string) (string, bool) {
+	target := link
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(path), link)
+	}
+	if resolved, err := filepath.EvalSymlinks(target); err == nil {
+		target = resolved
+	}
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return target, true
+}
+
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// snapshot records the size, mode and content hash of every file under root.
+func snapshot(root string) (map[string]fileState, error) {
+	states := map[string]fileState{}
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// The command may have made parts of the sandbox unreadable
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+
+		state := fileState{size: info.Size(), mode: info.Mode()}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			link, _ := os.Readlink(path)
+			state.hash = sha256.Sum256([]byte(link))
+		} else if info.Mode().IsRegular() {
+			state.hash, err = hashFile(path)
+			if err != nil {
+				return nil
+			}
+		}
+		states[rel] = state
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan the sandbox: %w", err)
+	}
+	return states, nil
+}
+
+func hashFile(path string) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+	file, err := os.Open(path)
+	if err != nil {
+		return sum, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return sum, err
+	}
+	copy(sum[:], hash.Sum(nil))
+	return sum, nil
+}
+
+func diffSnapshots(before, after map[string]fileState) []Change {
+	var changes []Change
+	for path, old := range before {
+		current, exists := after[path]
+		switch {
+		case !exists:
+			changes = append(changes, Change{Path: path, Kind: "deleted", SizeBefore: old.size})
+		case current.hash != old.hash || current.mode != old.mode:
+			changes = append(changes, Change{Path: path, Kind: "modified", SizeBefore: old.size, SizeAfter: current.size})
+		}
+	}
+	for path, current := range after {
+		if _, existed := before[path]; !existed {
+			changes = append(changes, Change{Path: path, Kind: "created", SizeAfter: current.size})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+// networkCommands reach the network whatever their arguments.
+var networkCommands = map[string]bool{
+	"curl": true, "wget": true, "ssh": true, "scp": true, "sftp": true, "ftp": true, "rsync": true,
+	"nc": true, "ncat": true, "telnet": true, "ping": true, "dig": true, "nslookup": true, "host": true,
+	"apt": true, "apt-get": true, "yum": true, "dnf": true, "brew": true, "pacman": true,
+}
+
+// networkSubcommands reach the network when run with one of the listed
+// subcommands, e.g. git clone.
+var networkSubcommands = map[string][]string{
+	"git":    {"clone", "fetch", "pull", "push", "ls-remote", "submodule"},
+	"npm":    {"install", "i", "ci", "update", "publish"},
+	"yarn":   {"install", "add", "upgrade"},
+	"pnpm":   {"install", "i", "add", "update"},
+	"pip":    {"install", "download"},
+	"pip3":   {"install", "download"},
+	"go":     {"get", "install", "mod"},
+	"cargo":  {"install", "fetch", "update", "build"},
+	"docker": {"pull", "push", "run", "build", "login"},
+	"gh":     {"pr", "issue", "repo", "api", "release"},
+}
+
+// Escapes lists what a command reaches outside the directory it runs in:
+// absolute and home paths, parent directories and network access. A dry run
+// cannot contain these, so they are reported before it starts.
+func Escapes(command, shellName string) []string {
+	file, err := parse(command, shellName)
+	if err != nil {
+		return []string{"the command could not be parsed: " + err.Error()}
+	}
+
+	var escapes []string
+	seen := map[string]bool{}
+	report := func(escape string) {
+		if !seen[escape] {
+			seen[escape] = true
+			escapes = append(escapes, escape)
+		}
+	}
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch node := node.(type) {
+		case *syntax.Redirect:
+			if target := wordString(node.Word); escapesDir(target) {
+				report("path " + target)
+			}
+		case *syntax.CallExpr:
+			args := make([]string, len(node.Args))
+			for i, word := range node.Args {
+				args[i] = wordString(word)
+			}
+			args, privileged := unwrap(args)
+			if privileged {
+				report("root privileges")
+			}
+			if len(args) == 0 {
+				return true
+			}
+
+			name := filepath.Base(args[0])
+			if networkCommands[name] {
+				report("network access with " + name)
+			}
+			if len(args) > 1 {
+				for _, subcommand := range networkSubcommands[name] {
+					if args[1] == subcommand {
+						report("network access with " + name + " " + subcommand)
+					}
+				}
+			}
+			for _, arg := range args[1:] {
+				// Values of options such as --output=/tmp/x and of=/dev/sda
+				if _, value, found := strings.Cut(arg, "="); found && escapesDir(value) {
+					report("path " + value)
+				} else if escapesDir(arg) {
+					report("path " + arg)
+				}
+			}
+		}
+		return true
+	})
+	return escapes
+}
+
+// escapesDir reports whether a path points outside the working directory.
+func escapesDir(path string) bool {
+	switch path {
+	case "", "/dev/null", "/dev/stdin", "/dev/stdout", "/dev/stderr":
+		return false
+	}
+	if strings.Contains(path, "://") {
+		return false
+	}
+	return strings.HasPrefix(path, "/") || path == "~" || strings.HasPrefix(path, "~/") ||
+		path == ".." || strings.HasPrefix(path, "../") || strings.Contains(path, "/../") ||
+		strings.HasPrefix(path, "$HOME") || strings.HasPrefix(path, "${HOME}")
+}
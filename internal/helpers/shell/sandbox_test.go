@@ -0,0 +1,107 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckTreeSize(t *testing.T) {
+	dir := t.TempDir()
+	modules := filepath.Join(dir, "node_modules")
+	if err := os.Mkdir(modules, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i <= MaxSandboxFiles; i++ {
+		if err := os.WriteFile(filepath.Join(modules, fmt.Sprintf("%d.js", i)), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkTreeSize(dir, nil); !errors.Is(err, ErrSandboxTooLarge) {
+		t.Errorf("err = %v, want ErrSandboxTooLarge for too many files", err)
+	}
+	skipModules := func(path string) bool { return filepath.Base(path) == "node_modules" }
+	if err := checkTreeSize(dir, skipModules); err != nil {
+		t.Errorf("err = %v with node_modules skipped, want none", err)
+	}
+
+	// A sparse file is over the size limit without using the disk space
+	big, err := os.Create(filepath.Join(dir, "disk.img"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer big.Close()
+	if err := big.Truncate(MaxSandboxBytes + 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkTreeSize(dir, skipModules); !errors.Is(err, ErrSandboxTooLarge) {
+		t.Errorf("err = %v, want ErrSandboxTooLarge for too many bytes", err)
+	}
+}
+
+func TestCopyTreeLinks(t *testing.T) {
+	base := t.TempDir()
+	src, outside := filepath.Join(base, "project"), filepath.Join(base, "outside")
+	for _, dir := range []string{src, outside} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	secret := filepath.Join(outside, "secret.txt")
+	for path, content := range map[string]string{filepath.Join(src, "notes.txt"): "notes\n", secret: "secret\n"} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		"relative":  "notes.txt",
+		"absolute":  filepath.Join(src, "notes.txt"),
+		"dangling":  "missing.txt",
+		"climbs":    "../outside/secret.txt",
+		"elsewhere": secret,
+		"etc":       "/etc",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(src, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dst := t.TempDir()
+	_, skipped, err := copyTree(src, dst, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skipped) != 3 {
+		t.Errorf("skipped links are %v, want climbs, elsewhere and etc", skipped)
+	}
+	for _, name := range []string{"relative", "absolute"} {
+		data, err := os.ReadFile(filepath.Join(dst, name))
+		if err != nil || string(data) != "notes\n" {
+			t.Errorf("%s reads %q, %v, want the copied notes", name, data, err)
+		}
+		if link, _ := os.Readlink(filepath.Join(dst, name)); filepath.IsAbs(link) {
+			t.Errorf("%s points to %s, want a link inside the sandbox", name, link)
+		}
+	}
+	for _, name := range []string{"climbs", "elsewhere", "etc"} {
+		if _, err := os.Lstat(filepath.Join(dst, name)); err == nil {
+			t.Errorf("%s was copied into the sandbox", name)
+		}
+	}
+
+	// Writing through the link in a dry run must not reach the real file
+	if _, err := DryRun(context.Background(), "sh", "echo changed > climbs", src, nil); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(secret); string(data) != "secret\n" {
+		t.Errorf("the dry run changed the file outside the directory to %q", data)
+	}
+}
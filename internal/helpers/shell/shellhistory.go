@@ -0,0 +1,234 @@
+package shell
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// JoinArgs turns the words of a command back into a single line for the
+// named shell, quoting the words that need it the way that shell reads them.
+func JoinArgs(args []string, shellName string) string {
+	if len(args) == 1 {
+		// A single word is a command line already, e.g. "make && make install"
+		return args[0]
+	}
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = Quote(arg, shellName)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// plainWord matches words no shell needs quoted.
+var plainWord = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// Quote quotes a word for the named shell, if it needs quoting.
+func Quote(word, shellName string) string {
+	if plainWord.MatchString(word) {
+		return word
+	}
+	switch shellName {
+	case "fish":
+		// Backslashes and quotes are the only escapes in fish single quotes
+		return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(word) + "'"
+	case "pwsh":
+		return "'" + strings.ReplaceAll(word, "'", "''") + "'"
+	case "csh", "tcsh":
+		// History expansion happens even inside single quotes
+		return "'" + strings.NewReplacer("'", `'\''`, "!", `\!`).Replace(word) + "'"
+	case "nu":
+		if !strings.Contains(word, "'") {
+			return "'" + word + "'"
+		}
+		return "r#'" + word + "'#"
+	}
+	lang, ok := dialects[shellName]
+	if !ok {
+		lang = syntax.LangPOSIX
+	}
+	quoted, err := syntax.Quote(word, lang)
+	if err != nil {
+		// Only control characters POSIX sh cannot quote get here
+		return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+	}
+	return quoted
+}
+
+// HistoryFile returns the history file of the user's shell: $HISTFILE for
+// bash and zsh, or the default location of the shell.
+func HistoryFile(env Environment) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	switch env.Shell {
+	case "fish":
+		dataDir := os.Getenv("XDG_DATA_HOME")
+		if dataDir == "" {
+			dataDir = filepath.Join(home, ".local", "share")
+		}
+		return filepath.Join(dataDir, "fish", "fish_history"), nil
+	case "zsh":
+		if file := os.Getenv("HISTFILE"); file != "" {
+			return file, nil
+		}
+		return filepath.Join(home, ".zsh_history"), nil
+	case "bash", "sh":
+		if file := os.Getenv("HISTFILE"); file != "" {
+			return file, nil
+		}
+		return filepath.Join(home, ".bash_history"), nil
+	}
+	return "", fmt.Errorf("reading the history of %s is not supported", env.Shell)
+}
+
+// ErrStaleHistory is returned by LastCommand when the shell genie runs in has
+// not written its history file since it started, so the last command in the
+// file is from an earlier session.
+var ErrStaleHistory = errors.New("the history file was not written since this shell started")
+
+// LastCommand returns the most recent command in the shell's history file
+// that skip does not reject, e.g. to leave out the genie invocation itself.
+//
+// Bash and zsh write their history when they exit, unless told to append
+// each command, so for them the file must have been written since the
+// parent shell started.
+func LastCommand(env Environment, skip func(command string) bool) (string, error) {
+	path, err := HistoryFile(env)
+	if err != nil {
+		return "", err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if env.Shell != "fish" && env.ShellSource == "parent process" {
+		info, err := file.Stat()
+		if err != nil {
+			return "", err
+		}
+		// ps reports whole seconds, so allow one second of slack
+		if started, err := parentStarted(); err == nil && info.ModTime().Before(started.Add(-time.Second)) {
+			return "", fmt.Errorf("%w: %s was last written at %s", ErrStaleHistory, path, info.ModTime().Format("Jan 2 15:04"))
+		}
+	}
+
+	var commands []string
+	var pending string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch env.Shell {
+		case "fish":
+			// - cmd: make build
+			if command, found := strings.CutPrefix(line, "- cmd: "); found {
+				commands = append(commands, unescapeFish(command))
+			}
+			continue
+		case "zsh":
+			// : 1700000000:0;make build, when EXTENDED_HISTORY is on
+			if strings.HasPrefix(line, ": ") && pending == "" {
+				if _, command, found := strings.Cut(line, ";"); found {
+					line = command
+				}
+			}
+		case "bash", "sh":
+			// #1700000000 timestamps, when HISTTIMEFORMAT is set
+			if strings.HasPrefix(line, "#") && pending == "" {
+				continue
+			}
+		}
+
+		// Multi-line commands end their lines with a backslash
+		if strings.HasSuffix(line, "\\") {
+			pending += strings.TrimSuffix(line, "\\") + "\n"
+			continue
+		}
+		commands = append(commands, pending+line)
+		pending = ""
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	for i := len(commands) - 1; i >= 0; i-- {
+		command := strings.TrimSpace(commands[i])
+		if command != "" && (skip == nil || !skip(command)) {
+			return command, nil
+		}
+	}
+	return "", errors.New("no command found in " + path)
+}
+
+// unescapeFish undoes the escaping of fish_history, which writes newlines
+// as \n and backslashes as \\.
+func unescapeFish(command string) string {
+	var sb strings.Builder
+	for i := 0; i < len(command); i++ {
+		if command[i] == '\\' && i+1 < len(command) {
+			switch command[i+1] {
+			case 'n':
+				sb.WriteByte('\n')
+				i++
+				continue
+			case '\\':
+				sb.WriteByte('\\')
+				i++
+				continue
+			}
+		}
+		sb.WriteByte(command[i])
+	}
+	return sb.String()
+}
+
+// parentStarted returns when the parent process started.
+func parentStarted() (time.Time, error) {
+	out, err := exec.Command("ps", "-o", "etime=", "-p", strconv.Itoa(os.Getppid())).Output()
+	if err != nil {
+		return time.Time{}, err
+	}
+	elapsed, err := parseElapsed(strings.TrimSpace(string(out)))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Now().Add(-elapsed), nil
+}
+
+// parseElapsed parses the [[dd-]hh:]mm:ss elapsed time ps prints for etime.
+func parseElapsed(text string) (time.Duration, error) {
+	var days int
+	if before, after, found := strings.Cut(text, "-"); found {
+		var err error
+		if days, err = strconv.Atoi(before); err != nil {
+			return 0, fmt.Errorf("invalid elapsed time %q", text)
+		}
+		text = after
+	}
+	parts := strings.Split(text, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid elapsed time %q", text)
+	}
+	seconds := 0
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, fmt.Errorf("invalid elapsed time %q", text)
+		}
+		seconds = seconds*60 + n
+	}
+	return time.Duration(days)*24*time.Hour + time.Duration(seconds)*time.Second, nil
+}
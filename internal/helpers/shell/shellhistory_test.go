@@ -0,0 +1,91 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJoinArgs(t *testing.T) {
+	tests := []struct {
+		shell string
+		args  []string
+		want  string
+	}{
+		{"bash", []string{"make && make install"}, "make && make install"},
+		{"bash", []string{"git", "commit", "-m", "it's done"}, `git commit -m "it's done"`},
+		{"sh", []string{"echo", "a b"}, "echo 'a b'"},
+		{"fish", []string{"echo", `it's a\b`}, `echo 'it\'s a\\b'`},
+		{"pwsh", []string{"echo", "it's"}, `echo 'it''s'`},
+		{"tcsh", []string{"echo", "hi!"}, `echo 'hi\!'`},
+		{"nu", []string{"echo", "a b"}, "echo 'a b'"},
+		{"nu", []string{"echo", "it's"}, "echo r#'it's'#"},
+	}
+	for _, test := range tests {
+		if got := JoinArgs(test.args, test.shell); got != test.want {
+			t.Errorf("JoinArgs(%q, %s) = %s, want %s", test.args, test.shell, got, test.want)
+		}
+	}
+}
+
+func TestLastCommand(t *testing.T) {
+	tests := []struct {
+		shell   string
+		history string
+		want    string
+	}{
+		{"bash", "ls\n#1700000000\nmake build\ngenie run --last\n", "make build"},
+		{"zsh", ": 1700000000:0;ls\n: 1700000001:0;for f in *; do\\\necho $f\\\ndone\n: 1700000002:0;genie run --last\n", "for f in *; do\necho $f\ndone"},
+		{"zsh", "ls\ngit status\n", "git status"},
+		{"fish", "- cmd: ls\n  when: 1700000000\n- cmd: printf 'a\\\\nb'\\nmake\n  when: 1700000001\n- cmd: genie run --last\n  when: 1700000002\n", "printf 'a\\nb'\nmake"},
+	}
+	for _, test := range tests {
+		t.Run(test.shell, func(t *testing.T) {
+			dir := t.TempDir()
+			file := filepath.Join(dir, "history")
+			if test.shell == "fish" {
+				t.Setenv("XDG_DATA_HOME", dir)
+				file = filepath.Join(dir, "fish", "fish_history")
+				if err := os.Mkdir(filepath.Dir(file), 0o755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Setenv("HISTFILE", file)
+			if err := os.WriteFile(file, []byte(test.history), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			env := Environment{Shell: test.shell, ShellSource: "$SHELL"}
+			skip := func(command string) bool { return strings.HasPrefix(command, "genie ") }
+			got, err := LastCommand(env, skip)
+			if err != nil || got != test.want {
+				t.Errorf("LastCommand = %q, %v, want %q", got, err, test.want)
+			}
+		})
+	}
+}
+
+func TestParseElapsed(t *testing.T) {
+	tests := []struct {
+		text string
+		want time.Duration
+	}{
+		{"00:05", 5 * time.Second},
+		{"12:34", 12*time.Minute + 34*time.Second},
+		{"01:02:03", time.Hour + 2*time.Minute + 3*time.Second},
+		{"2-03:00:00", 51 * time.Hour},
+	}
+	for _, test := range tests {
+		got, err := parseElapsed(test.text)
+		if err != nil || got != test.want {
+			t.Errorf("parseElapsed(%q) = %v, %v, want %v", test.text, got, err, test.want)
+		}
+	}
+	for _, text := range []string{"", "5", "a:b", "x-01:00"} {
+		if _, err := parseElapsed(text); err == nil {
+			t.Errorf("parseElapsed(%q) succeeded, want an error", text)
+		}
+	}
+}
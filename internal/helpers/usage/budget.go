@@ -0,0 +1,37 @@
+package usage
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrBudgetExceeded is returned when a request is refused because the
+// monthly budget has been spent.
+var ErrBudgetExceeded = errors.New("monthly budget exceeded")
+
+// Budget is a monthly spending limit in USD. A zero limit disables it.
+type Budget struct {
+	Limit float64
+	// Refuse makes requests fail once the limit is reached instead of only
+	// warning about it.
+	Refuse bool
+}
+
+// Check compares the spending of the current month against the budget and
+// returns the amount spent. It returns ErrBudgetExceeded when the budget
+// refuses further requests.
+func (b Budget) Check() (float64, error) {
+	if b.Limit <= 0 {
+		return 0, nil
+	}
+
+	spent, err := MonthToDate()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the usage ledger: %w", err)
+	}
+
+	if spent >= b.Limit && b.Refuse {
+		return spent, fmt.Errorf("%w: spent $%.2f of $%.2f this month", ErrBudgetExceeded, spent, b.Limit)
+	}
+	return spent, nil
+}
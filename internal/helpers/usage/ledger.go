@@ -0,0 +1,134 @@
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/harshalranjhani/genie/internal/config"
+)
+
+// Statuses of a ledger entry.
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+)
+
+// Entry is a single engine request recorded in the ledger.
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	Engine  string    `json:"engine"`
+	Model   string    `json:"model"`
+	// Status is StatusOK or StatusFailed, with the reason in Error. Entries
+	// written before failures were recorded have no status.
+	Status           string `json:"status,omitempty"`
+	Error            string `json:"error,omitempty"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+	// Estimated is set when the engine did not report token counts and they
+	// were derived from the text length instead.
+	Estimated bool    `json:"estimated"`
+	Cost      float64 `json:"cost"`
+}
+
+// Failed reports whether the request failed.
+func (e Entry) Failed() bool {
+	return e.Status == StatusFailed
+}
+
+// TotalTokens returns the prompt and completion tokens of the entry.
+func (e Entry) TotalTokens() int {
+	return e.PromptTokens + e.CompletionTokens
+}
+
+func getLedgerPath() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "usage.jsonl"), nil
+}
+
+// Record appends an entry to the ledger at ~/.genie/usage.jsonl. The cost is
+// computed from the price table when it is not set.
+func Record(entry Entry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	if entry.Cost == 0 {
+		entry.Cost = Cost(entry.Model, entry.PromptTokens, entry.CompletionTokens)
+	}
+
+	path, err := getLedgerPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// Load returns the ledger entries recorded at or after since.
+func Load(since time.Time) ([]Entry, error) {
+	path, err := getLedgerPath()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// Skip lines cut short by an interrupted write
+			continue
+		}
+		if !entry.Time.Before(since) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// StartOfMonth returns midnight on the first day of the current month.
+func StartOfMonth() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+}
+
+// MonthToDate returns the cost of all requests made this month.
+func MonthToDate() (float64, error) {
+	entries, err := Load(StartOfMonth())
+	if err != nil {
+		return 0, err
+	}
+
+	var total float64
+	for _, entry := range entries {
+		total += entry.Cost
+	}
+	return total, nil
+}
@@ -0,0 +1,80 @@
+package usage
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Price is the cost in USD per million tokens.
+type Price struct {
+	Input  float64
+	Output float64
+}
+
+// prices lists the public list prices of the hosted models. Models are
+// matched by the longest prefix, so dated snapshots such as
+// gpt-4o-2024-08-06 use the price of gpt-4o. Local models are free.
+var prices = map[string]Price{
+	"gpt-5":                 {Input: 1.25, Output: 10},
+	"gpt-5-mini":            {Input: 0.25, Output: 2},
+	"gpt-5-nano":            {Input: 0.05, Output: 0.40},
+	"gpt-4.1":               {Input: 2, Output: 8},
+	"gpt-4.1-mini":          {Input: 0.40, Output: 1.60},
+	"gpt-4.1-nano":          {Input: 0.10, Output: 0.40},
+	"gpt-4o":                {Input: 2.50, Output: 10},
+	"gpt-4o-mini":           {Input: 0.15, Output: 0.60},
+	"gpt-4-turbo":           {Input: 10, Output: 30},
+	"gpt-4":                 {Input: 30, Output: 60},
+	"gpt-3.5-turbo":         {Input: 0.50, Output: 1.50},
+	"o1":                    {Input: 15, Output: 60},
+	"o1-mini":               {Input: 1.10, Output: 4.40},
+	"o3":                    {Input: 2, Output: 8},
+	"o3-mini":               {Input: 1.10, Output: 4.40},
+	"o4-mini":               {Input: 1.10, Output: 4.40},
+	"gemini-2.5-pro":        {Input: 1.25, Output: 10},
+	"gemini-2.5-flash":      {Input: 0.30, Output: 2.50},
+	"gemini-2.5-flash-lite": {Input: 0.10, Output: 0.40},
+	"gemini-2.0-flash":      {Input: 0.10, Output: 0.40},
+	"gemini-2.0-flash-lite": {Input: 0.075, Output: 0.30},
+	"gemini-1.5-pro":        {Input: 1.25, Output: 5},
+	"gemini-1.5-flash":      {Input: 0.075, Output: 0.30},
+	"deepseek-chat":         {Input: 0.27, Output: 1.10},
+	"deepseek-reasoner":     {Input: 0.55, Output: 2.19},
+}
+
+// priceKeys holds the keys of prices, longest first.
+var priceKeys = func() []string {
+	keys := make([]string, 0, len(prices))
+	for key := range prices {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return len(keys[i]) > len(keys[j]) })
+	return keys
+}()
+
+// PriceOf returns the price of a model, or false for unknown and local models.
+func PriceOf(model string) (Price, bool) {
+	model = strings.ToLower(model)
+	for _, key := range priceKeys {
+		if strings.HasPrefix(model, key) {
+			return prices[key], true
+		}
+	}
+	return Price{}, false
+}
+
+// Cost returns the cost in USD of a request to model.
+func Cost(model string, promptTokens, completionTokens int) float64 {
+	price, ok := PriceOf(model)
+	if !ok {
+		return 0
+	}
+	return (float64(promptTokens)*price.Input + float64(completionTokens)*price.Output) / 1_000_000
+}
+
+// EstimateTokens approximates the token count of text at four characters
+// per token, which is close enough for English prose and code.
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
@@ -0,0 +1,104 @@
+package usage
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Row aggregates the ledger entries sharing a key, such as a day or a model.
+type Row struct {
+	Key              string  `json:"key"`
+	Requests         int     `json:"requests"`
+	Failed           int     `json:"failed"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+	Estimated        bool    `json:"estimated"`
+}
+
+// TotalTokens returns the prompt and completion tokens of the row.
+func (r Row) TotalTokens() int {
+	return r.PromptTokens + r.CompletionTokens
+}
+
+// Group functions for Summarize.
+var (
+	ByDay     = func(e Entry) string { return e.Time.Local().Format("2006-01-02") }
+	ByModel   = func(e Entry) string { return e.Engine + "/" + e.Model }
+	ByCommand = func(e Entry) string { return e.Command }
+	Total     = func(e Entry) string { return "total" }
+)
+
+// Summarize groups entries by key and returns the rows sorted by key.
+func Summarize(entries []Entry, key func(Entry) string) []Row {
+	rows := map[string]*Row{}
+	for _, entry := range entries {
+		k := key(entry)
+		if k == "" {
+			k = "unknown"
+		}
+		row, ok := rows[k]
+		if !ok {
+			row = &Row{Key: k}
+			rows[k] = row
+		}
+		row.Requests++
+		if entry.Failed() {
+			row.Failed++
+		}
+		row.PromptTokens += entry.PromptTokens
+		row.CompletionTokens += entry.CompletionTokens
+		row.Cost += entry.Cost
+		row.Estimated = row.Estimated || entry.Estimated
+	}
+
+	result := make([]Row, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, *row)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
+
+// WriteCSV writes the entries as CSV with a header row.
+func WriteCSV(w io.Writer, entries []Entry) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"time", "command", "engine", "model", "prompt_tokens", "completion_tokens", "estimated", "cost", "status", "error"}); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		record := []string{
+			e.Time.Format(time.RFC3339),
+			e.Command,
+			e.Engine,
+			e.Model,
+			strconv.Itoa(e.PromptTokens),
+			strconv.Itoa(e.CompletionTokens),
+			strconv.FormatBool(e.Estimated),
+			strconv.FormatFloat(e.Cost, 'f', 6, 64),
+			e.Status,
+			e.Error,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON writes the entries as an indented JSON array.
+func WriteJSON(w io.Writer, entries []Entry) error {
+	if entries == nil {
+		entries = []Entry{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(entries)
+}
@@ -11,9 +11,9 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/gorilla/websocket"
+	"github.com/harshalranjhani/genie/internal/secrets"
 	"github.com/harshalranjhani/genie/internal/structs"
 	"github.com/spf13/cobra"
-	"github.com/zalando/go-keyring"
 )
 
 func GetStatusFilePath() (string, error) {
@@ -146,9 +146,9 @@ func TokenValid() (bool, error) {
 func VerifySubscriptionMiddleware(cmd *cobra.Command, args []string) error {
 	valid, err := TokenValid()
 	if !valid {
-		message := fmt.Sprintf(color.RedString("Subscription verification required: %v\n", err) +
+		message := color.RedString("Subscription verification required: %v\n", err) +
 			color.CyanString("Please run the following command to re-verify your email:\n") +
-			color.YellowString("\tgenie verify [email]\n"))
+			color.YellowString("\tgenie verify [email]\n")
 		fmt.Println(message)
 		return fmt.Errorf("subscription verification failed")
 	}
@@ -158,13 +158,13 @@ func VerifySubscriptionMiddleware(cmd *cobra.Command, args []string) error {
 
 func CheckKeyringSetup() error {
 	// Check if the service exists by attempting to read a key
-	_, err := keyring.Get("genie", "ignore_list_path")
+	_, err := secrets.Get("ignore_list_path")
 	if err != nil {
 		return fmt.Errorf("genie service not initialized. Please run 'genie init' to set up required configurations")
 	}
 
 	// Check for required fields
-	ignorePath, err := keyring.Get("genie", "ignore_list_path")
+	ignorePath, err := secrets.Get("ignore_list_path")
 	if err != nil || ignorePath == "" {
 		return fmt.Errorf("ignore list path not configured. Please run 'genie init' to set up required configurations")
 	}
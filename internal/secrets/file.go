@@ -0,0 +1,318 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/harshalranjhani/genie/internal/config"
+)
+
+const (
+	secretsFileName = "secrets.enc"
+	keyFileName     = "secrets.key"
+	kdfIterations   = 600000
+)
+
+// sealedFile is the on-disk format of the encrypted store. The secrets are a
+// JSON object sealed with AES-256-GCM under a key derived with PBKDF2 from the
+// passphrase or the key file contents.
+type sealedFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// fileStore keeps secrets in ~/.genie/secrets.enc for machines without an OS
+// keyring, such as SSH-only hosts, containers and CI.
+type fileStore struct {
+	mu      sync.Mutex
+	path    string
+	secrets map[string]string
+	salt    []byte
+	key     []byte
+}
+
+func newFileStore() (*fileStore, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return &fileStore{path: filepath.Join(configDir, secretsFileName)}, nil
+}
+
+func (f *fileStore) Name() string {
+	return "encrypted file (" + f.path + ")"
+}
+
+func (f *fileStore) Get(key string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.load(); err != nil {
+		return "", err
+	}
+	value, ok := f.secrets[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (f *fileStore) Set(key, value string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.load(); err != nil {
+		return err
+	}
+	f.secrets[key] = value
+	return f.save()
+}
+
+func (f *fileStore) Delete(key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.load(); err != nil {
+		return err
+	}
+	if _, ok := f.secrets[key]; !ok {
+		return ErrNotFound
+	}
+	delete(f.secrets, key)
+	return f.save()
+}
+
+// caveat warns when the secrets are sealed with the generated key file. It
+// sits next to them, so whoever can read one can read the other: the file is
+// obfuscated rather than encrypted.
+func (f *fileStore) caveat() string {
+	if os.Getenv(PassphraseEnvVar) != "" || os.Getenv(KeyFileEnvVar) != "" {
+		return ""
+	}
+	return fmt.Sprintf("%s is only obfuscated: its key is the generated %s next to it, so anyone who can read %s can read your API keys. Set %s, or %s to a key file kept elsewhere, to encrypt it.",
+		f.path, keyFileName, filepath.Dir(f.path), PassphraseEnvVar, KeyFileEnvVar)
+}
+
+// Purge deletes the secrets file. The key file is kept for the next init.
+func (f *fileStore) Purge() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.secrets, f.salt, f.key = nil, nil, nil
+	if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// load decrypts the file once per process. A missing file is an empty store.
+func (f *fileStore) load() error {
+	if f.secrets != nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		f.secrets = map[string]string{}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read secrets file: %w", err)
+	}
+
+	var sealed sealedFile
+	if err := json.Unmarshal(data, &sealed); err != nil {
+		return fmt.Errorf("failed to parse secrets file %s: %w", f.path, err)
+	}
+
+	material, err := keyMaterial(false)
+	if err != nil {
+		return err
+	}
+	key, err := deriveKey(material, sealed.Salt)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	plain, err := gcm.Open(nil, sealed.Nonce, sealed.Data, nil)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt %s: wrong %s or key file", f.path, PassphraseEnvVar)
+	}
+
+	secrets := map[string]string{}
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return fmt.Errorf("failed to parse secrets file %s: %w", f.path, err)
+	}
+	f.secrets, f.salt, f.key = secrets, sealed.Salt, key
+	return nil
+}
+
+// save encrypts the secrets with a fresh nonce and replaces the file.
+func (f *fileStore) save() error {
+	if f.key == nil {
+		material, err := keyMaterial(true)
+		if err != nil {
+			return err
+		}
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+		key, err := deriveKey(material, salt)
+		if err != nil {
+			return err
+		}
+		f.salt, f.key = salt, key
+	}
+
+	plain, err := json.Marshal(f.secrets)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(f.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(sealedFile{
+		Version: 1,
+		Salt:    f.salt,
+		Nonce:   nonce,
+		Data:    gcm.Seal(nil, nonce, plain, nil),
+	})
+	if err != nil {
+		return err
+	}
+
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	return nil
+}
+
+// keyMaterial returns the passphrase from GENIE_PASSPHRASE or else the
+// contents of the key file. With create set, a missing default key file is
+// generated so that headless machines work without any setup.
+func keyMaterial(create bool) (string, error) {
+	if passphrase := os.Getenv(PassphraseEnvVar); passphrase != "" {
+		return passphrase, nil
+	}
+
+	keyPath := os.Getenv(KeyFileEnvVar)
+	custom := keyPath != ""
+	if !custom {
+		configDir, err := config.GetConfigDir()
+		if err != nil {
+			return "", err
+		}
+		keyPath = filepath.Join(configDir, keyFileName)
+	}
+
+	data, err := os.ReadFile(keyPath)
+	if err == nil {
+		return string(data), nil
+	}
+	if !errors.Is(err, os.ErrNotExist) || custom || !create {
+		return "", fmt.Errorf("no key for the secrets file: set %s or provide the key file %s: %w", PassphraseEnvVar, keyPath, err)
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	key := hex.EncodeToString(raw)
+	if err := os.WriteFile(keyPath, []byte(key), 0600); err != nil {
+		return "", fmt.Errorf("failed to create key file: %w", err)
+	}
+	return key, nil
+}
+
+// Seal encrypts data under passphrase in the format of the secrets file, for
+// secrets that leave the machine, such as an exported configuration.
+func Seal(passphrase string, data []byte) ([]byte, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("a passphrase is required to encrypt secrets")
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(sealedFile{
+		Version: 1,
+		Salt:    salt,
+		Nonce:   nonce,
+		Data:    gcm.Seal(nil, nonce, data, nil),
+	})
+}
+
+// Open decrypts data sealed with Seal.
+func Open(passphrase string, sealed []byte) ([]byte, error) {
+	var file sealedFile
+	if err := json.Unmarshal(sealed, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse encrypted secrets: %w", err)
+	}
+
+	key, err := deriveKey(passphrase, file.Salt)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secrets: wrong passphrase")
+	}
+	return data, nil
+}
+
+func deriveKey(material string, salt []byte) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, material, salt, kdfIterations, 32)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,100 @@
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	data := []byte(`{"openai_api_key": "sk-test"}`)
+	sealed, err := Seal("correct horse", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, []byte("sk-test")) {
+		t.Error("the sealed data contains the secret in plain text")
+	}
+
+	opened, err := Open("correct horse", sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(opened, data) {
+		t.Errorf("Open = %s, want %s", opened, data)
+	}
+
+	if _, err := Open("wrong horse", sealed); err == nil {
+		t.Error("Open with the wrong passphrase succeeded")
+	}
+	if _, err := Seal("", data); err == nil {
+		t.Error("Seal without a passphrase succeeded")
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(KeyFileEnvVar, "")
+	t.Setenv(PassphraseEnvVar, "correct horse")
+
+	store, err := newFileStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get("openai_api_key"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get on an empty store = %v, want ErrNotFound", err)
+	}
+	if err := store.Set("openai_api_key", "sk-test"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(store.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "sk-test") {
+		t.Error("the secrets file contains the secret in plain text")
+	}
+
+	// A new store decrypts the file written by the first one
+	reopened, err := newFileStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, err := reopened.Get("openai_api_key"); err != nil || value != "sk-test" {
+		t.Errorf("Get = %q, %v, want sk-test", value, err)
+	}
+
+	t.Setenv(PassphraseEnvVar, "wrong horse")
+	locked, err := newFileStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := locked.Get("openai_api_key"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Get with the wrong passphrase = %v, want a decryption error", err)
+	}
+}
+
+func TestFileStoreCaveat(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	store, err := newFileStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(PassphraseEnvVar, "")
+	t.Setenv(KeyFileEnvVar, "")
+	if caveat := store.caveat(); !strings.Contains(caveat, "obfuscated") {
+		t.Errorf("caveat with the generated key file is %q, want a warning", caveat)
+	}
+	t.Setenv(KeyFileEnvVar, "/media/usb/genie.key")
+	if caveat := store.caveat(); caveat != "" {
+		t.Errorf("caveat with a key file elsewhere is %q, want none", caveat)
+	}
+	t.Setenv(KeyFileEnvVar, "")
+	t.Setenv(PassphraseEnvVar, "correct horse")
+	if caveat := store.caveat(); caveat != "" {
+		t.Errorf("caveat with a passphrase is %q, want none", caveat)
+	}
+}
@@ -0,0 +1,44 @@
+package secrets
+
+import (
+	"errors"
+
+	"github.com/zalando/go-keyring"
+)
+
+const serviceName = "genie"
+
+// keyringStore keeps secrets in the OS keyring (Keychain, Credential Manager
+// or the D-Bus Secret Service).
+type keyringStore struct{}
+
+func (keyringStore) Name() string {
+	return "OS keyring"
+}
+
+func (keyringStore) Get(key string) (string, error) {
+	value, err := keyring.Get(serviceName, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrNotFound
+	}
+	return value, err
+}
+
+func (keyringStore) Set(key, value string) error {
+	return keyring.Set(serviceName, key, value)
+}
+
+func (keyringStore) Delete(key string) error {
+	err := keyring.Delete(serviceName, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// keyringAvailable reports whether the OS keyring answers at all. A missing
+// key is an answer; a missing Secret Service on a headless box is not.
+func keyringAvailable() bool {
+	_, err := keyring.Get(serviceName, "ignore_list_path")
+	return err == nil || errors.Is(err, keyring.ErrNotFound)
+}
@@ -0,0 +1,279 @@
+// Package secrets stores genie's API keys and settings. Values are read from
+// GENIE_* environment variables first, then from the project's .genie.yaml,
+// the active profile and finally a writable store: the OS keyring when one is
+// available, or an encrypted file in ~/.genie otherwise.
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/harshalranjhani/genie/internal/config"
+)
+
+const (
+	// BackendEnvVar forces the writable store: "keyring" or "file".
+	BackendEnvVar = "GENIE_SECRET_BACKEND"
+	// PassphraseEnvVar holds the passphrase of the encrypted file store.
+	PassphraseEnvVar = "GENIE_PASSPHRASE"
+	// KeyFileEnvVar points to the key file of the encrypted file store when
+	// no passphrase is set (default ~/.genie/secrets.key).
+	KeyFileEnvVar = "GENIE_KEY_FILE"
+)
+
+// ErrNotFound is returned when a secret is not set in any store.
+var ErrNotFound = errors.New("secret not found")
+
+// Store is a backend that keeps secrets by key.
+type Store interface {
+	// Name describes the store for status output.
+	Name() string
+	Get(key string) (string, error)
+	Set(key, value string) error
+	Delete(key string) error
+}
+
+var (
+	storeOnce sync.Once
+	store     Store
+	storeErr  error
+)
+
+// SetStore replaces the writable store, mainly for embedding genie elsewhere.
+func SetStore(s Store) {
+	storeOnce.Do(func() {})
+	store, storeErr = s, nil
+}
+
+// activeStore picks the writable store once per process: the OS keyring when
+// it answers, the encrypted file otherwise.
+func activeStore() (Store, error) {
+	storeOnce.Do(func() {
+		switch backend := strings.ToLower(os.Getenv(BackendEnvVar)); backend {
+		case "keyring":
+			store = keyringStore{}
+		case "file":
+			store, storeErr = newFileStore()
+		case "":
+			if keyringAvailable() {
+				store = keyringStore{}
+			} else {
+				store, storeErr = newFileStore()
+			}
+		default:
+			storeErr = fmt.Errorf("unknown %s %q, use keyring or file", BackendEnvVar, backend)
+		}
+	})
+	return store, storeErr
+}
+
+// Layers a value can come from, in order of precedence.
+const (
+	LayerEnv = iota
+	LayerProject
+	LayerProfile
+	LayerStore
+)
+
+// Value is a secret together with where it was found.
+type Value struct {
+	Value string
+	// Source describes the origin for status output, e.g. "profile work".
+	Source string
+	Layer  int
+}
+
+// Get returns the secret for key from its environment variable, the project,
+// the active profile or the writable store. It returns ErrNotFound when none
+// has it.
+func Get(key string) (string, error) {
+	value, err := Lookup(key)
+	return value.Value, err
+}
+
+// Lookup is Get that also reports where the value came from.
+func Lookup(key string) (Value, error) {
+	return LookupFrom(key, LayerEnv)
+}
+
+// LookupFrom is Lookup that skips the layers above layer. It tells what a
+// lower layer would use, e.g. which engine a profile's model was picked for.
+func LookupFrom(key string, layer int) (Value, error) {
+	if layer <= LayerEnv {
+		name := EnvName(key)
+		if value := os.Getenv(name); value != "" {
+			return Value{Value: value, Source: "environment (" + name + ")", Layer: LayerEnv}, nil
+		}
+	}
+
+	if layer <= LayerProject {
+		if project, err := config.LoadProject(); err == nil && project != nil {
+			if value, ok := project.Setting(key); ok {
+				return Value{Value: value, Source: "project (" + project.Path + ")", Layer: LayerProject}, nil
+			}
+		}
+	}
+
+	profile, active, err := config.ActiveProfile()
+	if err != nil {
+		return Value{}, err
+	}
+	if active && layer <= LayerProfile {
+		if value, ok := profile.Setting(key); ok {
+			return Value{Value: value, Source: "profile " + profile.Name, Layer: LayerProfile}, nil
+		}
+		if entry, ok := profile.Keys[key]; ok {
+			s, err := activeStore()
+			if err != nil {
+				return Value{}, err
+			}
+			value, err := s.Get(entry)
+			return Value{Value: value, Source: "profile " + profile.Name, Layer: LayerProfile}, err
+		}
+	}
+
+	s, err := activeStore()
+	if err != nil {
+		return Value{}, err
+	}
+	value, err := s.Get(key)
+	return Value{Value: value, Source: s.Name(), Layer: LayerStore}, err
+}
+
+// Stored returns the secret for key from the writable store alone, leaving
+// out the environment, the project and the active profile.
+func Stored(key string) (string, error) {
+	s, err := activeStore()
+	if err != nil {
+		return "", err
+	}
+	return s.Get(key)
+}
+
+// SetStored stores the secret for key in the writable store, even while a
+// profile is active.
+func SetStored(key, value string) error {
+	s, err := activeStore()
+	if err != nil {
+		return err
+	}
+	return s.Set(key, value)
+}
+
+// Set stores the secret for key. While a profile is active, its settings and
+// API keys are changed instead of the global ones.
+func Set(key, value string) error {
+	profile, active, err := config.ActiveProfile()
+	if err != nil {
+		return err
+	}
+	if active {
+		if profile.SetSetting(key, value) {
+			return config.SaveProfile(profile)
+		}
+		if entry, ok := profile.Keys[key]; ok {
+			key = entry
+		}
+	}
+
+	s, err := activeStore()
+	if err != nil {
+		return err
+	}
+	return s.Set(key, value)
+}
+
+// Delete removes the secret for key, from the active profile if it has one.
+// Environment variables are left alone.
+func Delete(key string) error {
+	profile, active, err := config.ActiveProfile()
+	if err != nil {
+		return err
+	}
+	if active {
+		if _, ok := profile.Setting(key); ok {
+			profile.SetSetting(key, "")
+			return config.SaveProfile(profile)
+		}
+		if entry, ok := profile.Keys[key]; ok {
+			delete(profile.Keys, key)
+			if err := config.SaveProfile(profile); err != nil {
+				return err
+			}
+			key = entry
+		}
+	}
+
+	s, err := activeStore()
+	if err != nil {
+		return err
+	}
+	return s.Delete(key)
+}
+
+// Purge removes the whole store where the backend supports it, such as the
+// encrypted file. Keyring entries have to be deleted key by key.
+func Purge() error {
+	s, err := activeStore()
+	if err != nil {
+		return err
+	}
+	if p, ok := s.(interface{ Purge() error }); ok {
+		return p.Purge()
+	}
+	return nil
+}
+
+// Backend describes the writable store in use.
+func Backend() string {
+	s, err := activeStore()
+	if err != nil {
+		return fmt.Sprintf("unavailable (%v)", err)
+	}
+	return s.Name()
+}
+
+// Caveat returns a warning about how well the active store protects the
+// secrets, or "" when there is nothing to warn about.
+func Caveat() string {
+	s, err := activeStore()
+	if err != nil {
+		return ""
+	}
+	if f, ok := s.(*fileStore); ok {
+		return f.caveat()
+	}
+	return ""
+}
+
+// envNames are the environment variables of keys that are not named after
+// the key, so that the engine and the model have a single override each:
+// GENIE_ENGINE, which the commands read too, rather than GENIE_ENGINE_NAME.
+var envNames = map[string]string{
+	"engineName": "GENIE_ENGINE",
+	"modelName":  "GENIE_MODEL",
+}
+
+// EnvName returns the environment variable that overrides key, e.g.
+// GENIE_OPENAI_API_KEY for openai_api_key and GENIE_ENGINE for engineName.
+func EnvName(key string) string {
+	if name, ok := envNames[key]; ok {
+		return name
+	}
+	var sb strings.Builder
+	sb.WriteString("GENIE_")
+	for i, r := range key {
+		if unicode.IsUpper(r) && i > 0 {
+			sb.WriteByte('_')
+		}
+		if r == '-' || r == '.' {
+			r = '_'
+		}
+		sb.WriteRune(unicode.ToUpper(r))
+	}
+	return sb.String()
+}
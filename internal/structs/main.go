@@ -64,7 +64,10 @@ type Engine struct {
 	Name         string
 	Models       []string
 	DefaultModel string
-	Features     EngineFeatures
+	// ContextWindow is the context size in tokens assumed for models that
+	// are not listed in config.ContextWindow
+	ContextWindow int
+	Features      EngineFeatures
 }
 
 // EngineFeatures represents supported features for an engine
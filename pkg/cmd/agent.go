@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/harshalranjhani/genie/internal/helpers"
+	"github.com/harshalranjhani/genie/internal/helpers/history"
+	"github.com/harshalranjhani/genie/internal/helpers/llm"
+	"github.com/harshalranjhani/genie/internal/helpers/shell"
+	"github.com/harshalranjhani/genie/pkg/prompts"
+)
+
+// maxStepOutput is how much of a step's output is fed back to the model. The
+// end of the output is kept, where errors and totals usually are.
+const maxStepOutput = 4000
+
+// agentStep is a command run by the agent and what it printed.
+type agentStep struct {
+	command     string
+	explanation string
+	output      string
+	exitCode    int
+}
+
+// agentRun holds what runAgent needs besides the task.
+type agentRun struct {
+	provider    llm.Provider
+	snapshot    string
+	environment string
+	// shell is the name of the shell the commands are run with.
+	shell string
+	// opts are the options of every request, e.g. safe mode.
+	opts      llm.Options
+	policy    *shell.Policy
+	assumeYes bool
+	maxSteps  int
+	// record is the history entry each step is recorded from.
+	record history.Entry
+}
+
+// runAgent asks the model for one command at a time, runs each after
+// confirmation and feeds its output back, until the model says it is done or
+// the step limit is reached. It ends with a transcript of all steps.
+func runAgent(task string, run agentRun) {
+	steps, summary, finished := agentSteps(task, run)
+
+	printTranscript(steps)
+	switch {
+	case finished:
+		color.Green("✨ Done")
+		if summary != "" {
+			fmt.Println(summary)
+		}
+	case len(steps) == run.maxSteps:
+		color.Yellow("Stopped after %d steps without finishing. Raise --max-steps to allow more.", run.maxSteps)
+		os.Exit(1)
+	default:
+		color.Yellow("Stopped before the task was finished.")
+		os.Exit(1)
+	}
+}
+
+// agentSteps runs the steps of runAgent and returns them, with the summary of
+// the model and whether it said the task is finished.
+func agentSteps(task string, run agentRun) ([]agentStep, string, bool) {
+	var steps []agentStep
+	summary, finished := "", false
+
+	for len(steps) < run.maxSteps {
+		var sb strings.Builder
+		sb.WriteString(run.snapshot)
+		prompt := prompts.GetAgentPrompt(sb, task, run.environment, formatSteps(steps), run.maxSteps-len(steps))
+
+		var proposal agentProposal
+		s := newSpinner(fmt.Sprintf("Planning step %d: ", len(steps)+1))
+		ctx, cancel := requestContext()
+		err := llm.CompleteJSON(ctx, run.provider, prompt, agentSchema, run.opts, &proposal, proposal.validate)
+		cancel()
+		s.Stop()
+		if errors.Is(err, llm.ErrUnsafeContent) {
+			fmt.Println("The generated command contains inappropriate content.")
+			break
+		}
+		if errors.Is(err, llm.ErrInvalidJSON) {
+			color.Red("The genie did not propose a usable command: %v", err)
+			break
+		}
+		if err != nil {
+			exitIfCancelled(err)
+			color.Red("Request failed: %v", err)
+			break
+		}
+		reportFallback(run.provider)
+
+		if proposal.Done {
+			summary, finished = proposal.Summary, true
+			break
+		}
+
+		command, explanation := proposal.Command, proposal.Explanation
+		color.HiMagenta("\n🪜 Step %d of at most %d", len(steps)+1, run.maxSteps)
+		printProposalNotes(proposal.doProposal, run.shell)
+		record := run.record
+		record.Engine, record.Model = run.provider.Name(), run.provider.Model()
+		record.Step, record.Command, record.Explanation = len(steps)+1, command, explanation
+		command, ok := confirmCommand(command, explanation, run.shell, run.assumeYes, run.policy)
+		if !ok {
+			recordHistory(record)
+			break
+		}
+		if command != record.Command {
+			record.Command, record.Explanation = command, ""
+			explanation = ""
+		}
+
+		fmt.Println("Running the command: ", command)
+		output, exitCode, err := runRecorded(record)
+		if err != nil {
+			color.Red("Failed to run the command: %v", err)
+			break
+		}
+		steps = append(steps, agentStep{command: command, explanation: explanation, output: output, exitCode: exitCode})
+	}
+	return steps, summary, finished
+}
+
+// formatSteps describes the steps so far for the prompt.
+func formatSteps(steps []agentStep) string {
+	var sb strings.Builder
+	for i, step := range steps {
+		output := strings.TrimSpace(step.output)
+		if len(output) > maxStepOutput {
+			output = "[earlier output omitted]\n" + helpers.SanitizeUTF8(output[len(output)-maxStepOutput:])
+		}
+		if output == "" {
+			output = "(no output)"
+		}
+		fmt.Fprintf(&sb, "Step %d: %s\nExit code: %d\nOutput:\n%s\n\n", i+1, step.command, step.exitCode, output)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func printTranscript(steps []agentStep) {
+	if len(steps) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Println(color.HiMagentaString("📜 Transcript"))
+	fmt.Println(strings.Repeat("─", 50))
+	for i, step := range steps {
+		color.Cyan("Step %d: %s", i+1, step.command)
+		if step.explanation != "" {
+			color.HiBlack("  %s", step.explanation)
+		}
+		if output := strings.TrimSpace(step.output); output != "" {
+			fmt.Print(indent(output))
+		}
+		if step.exitCode == 0 {
+			color.Green("  exit code 0")
+		} else {
+			color.Red("  exit code %d", step.exitCode)
+		}
+	}
+	fmt.Println(strings.Repeat("─", 50))
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/harshalranjhani/genie/internal/helpers/llm"
+	"github.com/harshalranjhani/genie/internal/helpers/shell"
+)
+
+// scriptedProvider answers requests with its responses in order and keeps
+// the prompts it was sent.
+type scriptedProvider struct {
+	responses []string
+	prompts   []string
+}
+
+func (p *scriptedProvider) Name() string  { return "Gemini" }
+func (p *scriptedProvider) Model() string { return "scripted" }
+
+func (p *scriptedProvider) Complete(ctx context.Context, prompt string, opts llm.Options) (string, error) {
+	i := len(p.prompts)
+	p.prompts = append(p.prompts, prompt)
+	if i >= len(p.responses) {
+		return "", errors.New("no more responses")
+	}
+	return p.responses[i], nil
+}
+
+func (p *scriptedProvider) Stream(ctx context.Context, prompt string, opts llm.Options) (llm.Stream, error) {
+	return nil, errors.New("not supported")
+}
+
+func (p *scriptedProvider) Chat(ctx context.Context, messages []llm.Message, opts llm.Options) (llm.Stream, error) {
+	return nil, errors.New("not supported")
+}
+
+func (p *scriptedProvider) ListModels(ctx context.Context) ([]string, error) {
+	return nil, errors.New("not supported")
+}
+
+// step is the answer of the model proposing command.
+func step(t *testing.T, command string) string {
+	t.Helper()
+	data, err := json.Marshal(agentProposal{doProposal: doProposal{Command: command, Explanation: "runs " + command, Risk: "low", Alternatives: []string{}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// done is the answer of the model finishing the task.
+func done(summary string) string {
+	return `{"done": true, "summary": "` + summary + `", "command": "", "explanation": "", "risk": "low", "requires_sudo": false, "alternatives": []}`
+}
+
+func TestAgentSteps(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GENIE_PROFILE", "")
+	t.Chdir(t.TempDir())
+
+	tests := []struct {
+		name      string
+		responses []string
+		maxSteps  int
+		policy    *shell.Policy
+		// commands are the commands that ran, finished and summary what
+		// the loop ended with
+		commands []string
+		finished bool
+		summary  string
+	}{
+		{
+			name:      "done",
+			responses: []string{step(t, "echo first"), step(t, "echo second; exit 3"), done("All set")},
+			maxSteps:  5,
+			commands:  []string{"echo first", "echo second; exit 3"},
+			finished:  true,
+			summary:   "All set",
+		},
+		{
+			name:      "step limit",
+			responses: []string{step(t, "echo 1"), step(t, "echo 2"), step(t, "echo 3")},
+			maxSteps:  2,
+			commands:  []string{"echo 1", "echo 2"},
+		},
+		{
+			name:      "blocked",
+			responses: []string{step(t, "echo ok"), step(t, "rm -rf /"), step(t, "echo never")},
+			maxSteps:  5,
+			policy:    shell.DefaultPolicy(),
+			commands:  []string{"echo ok"},
+		},
+		{
+			name:      "invalid answer",
+			responses: []string{"I would run ls", "Sure: ls -la"},
+			maxSteps:  5,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := &scriptedProvider{responses: test.responses}
+			steps, summary, finished := agentSteps("test the agent", agentRun{
+				provider:  provider,
+				shell:     "sh",
+				policy:    test.policy,
+				assumeYes: true,
+				maxSteps:  test.maxSteps,
+			})
+
+			var commands []string
+			for _, step := range steps {
+				commands = append(commands, step.command)
+			}
+			if strings.Join(commands, "\n") != strings.Join(test.commands, "\n") {
+				t.Errorf("ran %q, want %q", commands, test.commands)
+			}
+			if finished != test.finished || summary != test.summary {
+				t.Errorf("ended with %v, %q, want %v, %q", finished, summary, test.finished, test.summary)
+			}
+		})
+	}
+}
+
+func TestAgentFeedsOutputBack(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GENIE_PROFILE", "")
+
+	provider := &scriptedProvider{responses: []string{step(t, "echo first"), step(t, "echo oops; exit 3"), done("Done")}}
+	steps, _, _ := agentSteps("test the agent", agentRun{provider: provider, shell: "sh", assumeYes: true, maxSteps: 5})
+	if len(steps) != 2 || steps[0].output != "first\n" || steps[1].exitCode != 3 {
+		t.Fatalf("steps are %+v, want the output of echo and exit code 3", steps)
+	}
+
+	if strings.Contains(provider.prompts[0], "Step 1:") {
+		t.Error("the first prompt lists steps, want none")
+	}
+	if !strings.Contains(provider.prompts[1], "Step 1: echo first\nExit code: 0\nOutput:\nfirst") {
+		t.Errorf("the second prompt does not show the first step:\n%s", provider.prompts[1])
+	}
+	if !strings.Contains(provider.prompts[2], "Step 2: echo oops; exit 3\nExit code: 3\nOutput:\noops") {
+		t.Errorf("the third prompt does not show the failed step:\n%s", provider.prompts[2])
+	}
+}
+
+func TestFormatStepsKeepsTheEnd(t *testing.T) {
+	output := strings.Repeat("progress\n", maxStepOutput) + "error: disk full\n"
+	formatted := formatSteps([]agentStep{{command: "make", output: output, exitCode: 2}, {command: "true"}})
+	if !strings.Contains(formatted, "[earlier output omitted]") || !strings.Contains(formatted, "error: disk full") {
+		t.Errorf("long output is not cut from the start:\n%.200s", formatted)
+	}
+	if len(formatted) > maxStepOutput+200 {
+		t.Errorf("formatted steps are %d bytes, want about %d", len(formatted), maxStepOutput)
+	}
+	if !strings.HasSuffix(formatted, "Step 2: true\nExit code: 0\nOutput:\n(no output)") {
+		t.Errorf("a step without output is not marked:\n%s", formatted[len(formatted)-80:])
+	}
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/chzyer/readline"
+	"github.com/fatih/color"
+	"github.com/harshalranjhani/genie/internal/helpers/attach"
+	"github.com/harshalranjhani/genie/pkg/prompts"
+	"github.com/spf13/cobra"
+)
+
+// addFileFlag adds the repeatable --file flag of the commands that send
+// attachments to the engine.
+func addFileFlag(cmd *cobra.Command) {
+	cmd.Flags().StringArrayP("file", "f", nil, "Attach a file, or some of its lines with path:10-80 (repeatable)")
+}
+
+// readAttachments reads the piped input and the files given with --file and
+// shortens them to budget tokens together, the context budget of the request
+// they are sent with. It exits when one of them cannot be attached, or when
+// the budget leaves no room for them.
+func readAttachments(cmd *cobra.Command, budget int) []prompts.Attachment {
+	var attachments []attach.Attachment
+	piped, ok, err := attach.Stdin()
+	if err != nil {
+		color.Red("Failed to attach the piped input: %v", err)
+		os.Exit(1)
+	}
+	if ok {
+		attachments = append(attachments, piped)
+	}
+
+	specs, _ := cmd.Flags().GetStringArray("file")
+	for _, spec := range specs {
+		attachment, err := attach.File(spec)
+		if err != nil {
+			color.Red("Failed to attach %s: %v", spec, err)
+			os.Exit(1)
+		}
+		attachments = append(attachments, attachment)
+	}
+	if len(attachments) == 0 {
+		return nil
+	}
+	// A token is about four bytes, see usage.EstimateTokens
+	attachments, ok = attach.Fit(attachments, budget*4)
+	if !ok {
+		color.Red("The attachments do not fit into the context of the model (about %d tokens are left for them). Attach fewer files, or only some of their lines with --file path:10-80.", budget)
+		os.Exit(1)
+	}
+
+	var result []prompts.Attachment
+	var labels []string
+	for _, attachment := range attachments {
+		if attachment.Omitted > 0 {
+			color.Yellow("Attaching only the beginning and end of %s: %d lines are left out to fit into the context.", attachment.Label(), attachment.Omitted)
+		}
+		result = append(result, prompts.Attachment{Label: attachment.Label(), Content: attachment.Content})
+		labels = append(labels, attachment.Label())
+	}
+	color.Cyan("📎 Attached %s", strings.Join(labels, ", "))
+	return result
+}
+
+// useTerminalInput reads the following input from the terminal, once the
+// piped input has been attached, e.g. for the messages of a chat.
+func useTerminalInput() error {
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return err
+	}
+	os.Stdin = tty
+	readline.Stdin = tty
+	return nil
+}
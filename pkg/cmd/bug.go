@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,10 +9,9 @@ import (
 
 	"github.com/briandowns/spinner"
 	"github.com/fatih/color"
-	"github.com/harshalranjhani/genie/internal/config"
 	"github.com/harshalranjhani/genie/internal/helpers/llm"
+	"github.com/harshalranjhani/genie/pkg/prompts"
 	"github.com/spf13/cobra"
-	"github.com/zalando/go-keyring"
 )
 
 func init() {
@@ -23,6 +21,7 @@ func init() {
 	reportCmd.Flags().StringP("category", "c", "", "Bug category (ui, backend, security, performance, etc.)")
 	reportCmd.Flags().StringP("assignee", "a", "", "Who should be assigned to this bug")
 	reportCmd.Flags().StringP("priority", "p", "medium", "Bug priority (low, medium, high)")
+	addFileFlag(reportCmd)
 
 	bugCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
 		if len(args) == 0 {
@@ -60,22 +59,30 @@ Genie will analyze the issue and generate a structured report with:
 - Potential fixes
 - Severity and category classification
 
-All the information is saved in a markdown file in the bugs directory categorized by priority order.`,
+All the information is saved in a markdown file in the bugs directory categorized by priority order.
+Pipe a log into it or attach files with --file path or --file path:10-80.`,
 	Args: cobra.ExactArgs(1),
 	Run:  runBugReport,
 }
 
 func runBugReport(cmd *cobra.Command, args []string) {
-	s := spinner.New(spinner.CharSets[11], 100*time.Millisecond)
-	s.Prefix = color.HiCyanString("Analyzing bug report: ")
-	s.Start()
-
 	description := args[0]
 	severity, _ := cmd.Flags().GetString("severity")
 	category, _ := cmd.Flags().GetString("category")
 	assignee, _ := cmd.Flags().GetString("assignee")
 	priority, _ := cmd.Flags().GetString("priority")
 
+	provider, _, err := getProvider()
+	if err != nil {
+		color.Red("Error retrieving engine: %v", err)
+		return
+	}
+	attachments := readAttachments(cmd, contextBudget(provider, prompts.GetBugReportPrompt(description, severity, category, assignee, priority, nil)))
+
+	s := spinner.New(spinner.CharSets[11], 100*time.Millisecond)
+	s.Prefix = color.HiCyanString("Analyzing bug report: ")
+	s.Start()
+
 	// Get current time in local timezone instead of UTC
 	currentTime := time.Now()
 	formattedTime := currentTime.Format("2006-01-02 15:04:05 MST")
@@ -83,15 +90,8 @@ func runBugReport(cmd *cobra.Command, args []string) {
 	// Add timestamp to the beginning of the bug report template
 	bugReportPrefix := fmt.Sprintf("# Bug Report Created: %s\n\n", formattedTime)
 
-	engineName, err := keyring.Get(serviceName, "engineName")
-	if err != nil {
-		s.Stop()
-		color.Red("Error retrieving engine name: %v", err)
-		return
-	}
-
 	// Create bugs directory and priority subdirectory
-	bugsDir := filepath.Join(".", "bugs")
+	bugsDir, _ := bugOutputDir()
 	priorityDir := filepath.Join(bugsDir, strings.ToLower(priority))
 	if err := os.MkdirAll(priorityDir, 0755); err != nil {
 		s.Stop()
@@ -99,28 +99,17 @@ func runBugReport(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	var bugReport string
-	switch engineName {
-	case config.GPTEngine:
-		bugReport, err = llm.GenerateBugReportGPT(description, severity, category, assignee, priority)
-	case config.GeminiEngine:
-		bugReport, err = llm.GenerateBugReportGemini(description, severity, category, assignee, priority)
-	case config.DeepSeekEngine:
-		bugReport, err = llm.GenerateBugReportDeepSeek(description, severity, category, assignee, priority)
-	case config.OllamaEngine:
-		model, err := keyring.Get(serviceName, modelAccountKey)
-		if err != nil {
-			log.Fatal("Error retrieving model name from keyring: ", err)
-		}
-		bugReport, err = llm.GenerateBugReportOllama(description, severity, category, assignee, priority, model)
-	default:
-		s.Stop()
-		color.Red("Unknown engine: %s", engineName)
-		return
-	}
+	prompt := prompts.GetBugReportPrompt(description, severity, category, assignee, priority, attachments)
+	ctx, cancel := requestContext()
+	defer cancel()
 
+	bugReport, err := provider.Complete(ctx, prompt, llm.Options{
+		System:      "You are a helpful software engineer who writes clear, detailed bug reports.",
+		Temperature: 0.7,
+	})
 	if err != nil {
 		s.Stop()
+		exitIfCancelled(err)
 		color.Red("Error generating bug report: %v", err)
 		return
 	}
@@ -145,6 +134,7 @@ func runBugReport(cmd *cobra.Command, args []string) {
 	}
 
 	s.Stop()
+	reportFallback(provider)
 	color.Green("\n✓ Bug report generated successfully!")
 	fmt.Printf("\nLocation: %s\n", color.CyanString(filepath))
 }
@@ -2,63 +2,67 @@ package cmd
 
 import (
 	"log"
+	"os"
 
 	"github.com/fatih/color"
 	"github.com/harshalranjhani/genie/internal/config"
+	"github.com/harshalranjhani/genie/internal/helpers/attach"
 	"github.com/harshalranjhani/genie/internal/helpers/llm"
 	"github.com/harshalranjhani/genie/internal/middleware"
+	"github.com/harshalranjhani/genie/pkg/prompts"
 	"github.com/spf13/cobra"
-	"github.com/zalando/go-keyring"
 )
 
 func init() {
 	rootCmd.AddCommand(chatCmd)
 	chatCmd.PersistentFlags().Bool("safe", false, "Set this to true if you wish to enable safe mode.")
+	addFileFlag(chatCmd)
 }
 
 var chatCmd = &cobra.Command{
-	Use:     "chat",
-	Short:   "Start an interactive chat session",
-	Long:    `Start an interactive chat session with the AI model.`,
+	Use:   "chat",
+	Short: "Start an interactive chat session",
+	Long: `Start an interactive chat session with the AI model.
+Piped input and files given with --file are attached to the whole conversation:
+  genie chat --file main.go --file go.mod
+  cat error.log | genie chat`,
 	PreRunE: middleware.VerifySubscriptionMiddleware,
 	Run: func(cmd *cobra.Command, args []string) {
-		engineName, err := keyring.Get(serviceName, "engineName")
+		provider, engine, err := getProvider()
 		if err != nil {
-			log.Fatal("Error retrieving engine name from keyring:", err)
-		}
-
-		engine, exists := config.CheckAndGetEngine(engineName)
-		if !exists {
-			log.Fatal("Unknown engine name: ", engineName)
+			log.Fatal(err)
 		}
 
 		if !engine.Features.SupportsChat {
-			color.Red("%s engine does not support chat yet. Check back soon!", engineName)
+			color.Red("%s engine does not support chat yet. Check back soon!", engine.Name)
 			return
 		}
 
-		safeSettings, _ := cmd.Flags().GetBool("safe")
+		system := "You are a helpful assistant."
+		attachments := readAttachments(cmd, contextBudget(provider, system))
+		if attach.StdinPiped() {
+			// The piped input is attached, the messages come from the terminal
+			if err := useTerminalInput(); err != nil {
+				color.Red("genie chat needs a terminal for your messages: %v", err)
+				os.Exit(1)
+			}
+		}
+
+		safeSettings := safeModeFlag(cmd)
 
 		if safeSettings && engine.Features.SupportsSafeMode {
 			color.Green("Safety settings are on.")
-		}
-
-		switch engineName {
-		case config.GPTEngine:
-			if safeSettings {
+			if engine.Name == config.GPTEngine {
 				color.Yellow("Note: Safety settings in GPT are managed through OpenAI's content moderation.")
 			}
-			llm.StartGPTChat()
-		case config.GeminiEngine:
-			llm.StartGeminiChat(safeSettings)
-		case config.DeepSeekEngine:
-			llm.StartDeepSeekChat()
-		case config.OllamaEngine:
-			model, err := keyring.Get(serviceName, modelAccountKey)
-			if err != nil {
-				log.Fatal("Error retrieving model name from keyring: ", err)
-			}
-			llm.StartOllamaChat(model)
 		}
+
+		if len(attachments) > 0 {
+			system += "\n\n" + prompts.GetAttachmentsPrompt(attachments)
+		}
+		llm.StartChat(provider, llm.Options{
+			System:   system,
+			SafeMode: safeSettings,
+		}, requestTimeout)
 	},
 }
@@ -0,0 +1,350 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/harshalranjhani/genie/internal/config"
+	"github.com/harshalranjhani/genie/internal/secrets"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// configPassphraseEnvVar holds the passphrase of exported secrets so that
+// scripts do not have to answer a prompt.
+const configPassphraseEnvVar = "GENIE_CONFIG_PASSPHRASE"
+
+// setting is a value genie keeps in the secret store. Secret settings are
+// redacted from exports unless asked otherwise.
+type setting struct {
+	key    string
+	flag   string
+	usage  string
+	secret bool
+}
+
+var settings = []setting{
+	{openAIKeyName, "openai-key", "OpenAI API key", true},
+	{geminiKeyName, "gemini-key", "Gemini API key", true},
+	{deepseekKeyName, "deepseek-key", "DeepSeek API key", true},
+	{replicateKeyName, "replicate-key", "Replicate API key", true},
+	{ignoreListPathKeyName, "ignore-list", "Path to the ignore list file", false},
+	{ollamaURLKeyName, "ollama-url", "Ollama URL (default http://localhost:11434)", false},
+	{openAICompatibleURLKeyName, "compatible-url", "Base URL of an OpenAI-compatible server", false},
+	{openAICompatibleKeyName, "compatible-key", "API key of the OpenAI-compatible server", true},
+	{openAICompatibleModelsKeyName, "compatible-models", "Comma-separated models of the OpenAI-compatible server", false},
+	{accountName, "set-engine", "Engine to use (default Gemini)", false},
+	{modelAccountKey, "set-model", "Model to use (default the engine's default model)", false},
+	{fallbackChainKeyName, "fallback-chain", `Fallback chain, e.g. "Gemini -> GPT" or "off"`, false},
+	{monthlyBudgetKeyName, "monthly-budget", "Monthly budget in USD", false},
+	{budgetModeKeyName, "budget-mode", "What happens when the budget is spent: warn or refuse", false},
+}
+
+// normalizeSetting validates a value before it is stored and returns it in
+// the form the commands read back.
+func normalizeSetting(key, value string) (string, error) {
+	switch key {
+	case accountName:
+		engine, exists := config.CheckAndGetEngine(value)
+		if !exists {
+			return "", fmt.Errorf("unknown engine name: %s", value)
+		}
+		return engine.Name, nil
+	case fallbackChainKeyName:
+		if value == fallbackDisabled {
+			return value, nil
+		}
+		chain, err := config.ParseEngineChain(value)
+		if err != nil {
+			return "", err
+		}
+		return strings.Join(chain, ","), nil
+	case monthlyBudgetKeyName:
+		if limit, err := strconv.ParseFloat(value, 64); err != nil || limit < 0 {
+			return "", fmt.Errorf("invalid monthly budget %q", value)
+		}
+	case budgetModeKeyName:
+		if value != budgetWarn && value != budgetRefuse {
+			return "", fmt.Errorf("invalid budget mode %q, use %s or %s", value, budgetWarn, budgetRefuse)
+		}
+	}
+	return value, nil
+}
+
+// exportedConfig is the file written by genie config export. Secrets are
+// either listed as redacted or sealed with a passphrase.
+type exportedConfig struct {
+	Version  int               `json:"version"`
+	Settings map[string]string `json:"settings,omitempty"`
+	Redacted []string          `json:"redacted,omitempty"`
+	Secrets  json.RawMessage   `json:"secrets,omitempty"`
+	Profiles []config.Profile  `json:"profiles,omitempty"`
+}
+
+var (
+	configOutput         string
+	configIncludeSecrets bool
+	configPassphrase     string
+)
+
+func init() {
+	configExportCmd.Flags().StringVarP(&configOutput, "output", "o", "", "File to write the configuration to (default stdout)")
+	configExportCmd.Flags().BoolVar(&configIncludeSecrets, "include-secrets", false, "Include API keys, encrypted with a passphrase")
+	configExportCmd.Flags().StringVar(&configPassphrase, "passphrase", "", "Passphrase for the secrets (default "+configPassphraseEnvVar+" or a prompt)")
+	configImportCmd.Flags().StringVar(&configPassphrase, "passphrase", "", "Passphrase for the secrets (default "+configPassphraseEnvVar+" or a prompt)")
+
+	configCmd.AddCommand(configExportCmd, configImportCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Export and import genie's configuration",
+	Long: `Share a standard setup: export the configuration on one machine and import it on others.
+API keys are redacted unless --include-secrets is given, in which case they are encrypted with a passphrase.
+Imported keys go to the secret store. Without an OS keyring that is ~/.genie/secrets.enc, which is only obfuscated unless GENIE_PASSPHRASE or GENIE_KEY_FILE points to a key kept elsewhere.
+Example: genie config export -o team.json && genie config import team.json`,
+}
+
+var configExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write the configuration to a file",
+	Long: `Write the global configuration and the profiles to a file.
+Only stored values are exported: GENIE_* environment variables, the project's .genie.yaml and the settings of the active profile are left out.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		exported, err := exportConfig(configIncludeSecrets, func() string { return readConfigPassphrase(true) })
+		if err != nil {
+			fmt.Fprintln(os.Stderr, color.RedString("%v", err))
+			os.Exit(1)
+		}
+
+		data, err := json.MarshalIndent(exported, "", "  ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, color.RedString("Failed to encode the configuration: %v", err))
+			os.Exit(1)
+		}
+		data = append(data, '\n')
+
+		if configOutput == "" {
+			os.Stdout.Write(data)
+			if len(exported.Redacted) > 0 {
+				fmt.Fprintln(os.Stderr, color.YellowString("API keys were left out. Use --include-secrets to export them encrypted."))
+			}
+			return
+		}
+		if err := os.WriteFile(configOutput, data, 0600); err != nil {
+			color.Red("Failed to write %s: %v", configOutput, err)
+			os.Exit(1)
+		}
+		color.Green("✓ Configuration exported to %s", configOutput)
+		if len(exported.Redacted) > 0 {
+			color.Yellow("API keys were left out. Use --include-secrets to export them encrypted.")
+		}
+	},
+}
+
+// exportConfig collects the stored settings and the profiles. Secrets are
+// sealed with the passphrase when includeSecrets is set and listed as
+// redacted otherwise.
+func exportConfig(includeSecrets bool, passphrase func() string) (exportedConfig, error) {
+	exported := exportedConfig{Version: 1, Settings: map[string]string{}}
+	sealed := map[string]string{}
+
+	for _, s := range settings {
+		value, err := secrets.Stored(s.key)
+		if err != nil || value == "" {
+			continue
+		}
+		switch {
+		case !s.secret:
+			exported.Settings[s.key] = value
+		case includeSecrets:
+			sealed[s.key] = value
+		default:
+			exported.Redacted = append(exported.Redacted, s.key)
+		}
+	}
+
+	profiles, err := config.ListProfiles()
+	if err != nil {
+		return exportedConfig{}, fmt.Errorf("failed to read profiles: %w", err)
+	}
+	exported.Profiles = profiles
+	for _, profile := range profiles {
+		for _, entry := range profile.Keys {
+			value, err := secrets.Stored(entry)
+			if err != nil {
+				continue
+			}
+			if includeSecrets {
+				sealed[entry] = value
+			} else {
+				exported.Redacted = append(exported.Redacted, entry)
+			}
+		}
+	}
+
+	if includeSecrets && len(sealed) > 0 {
+		data, err := json.Marshal(sealed)
+		if err != nil {
+			return exportedConfig{}, fmt.Errorf("failed to encode secrets: %w", err)
+		}
+		exported.Secrets, err = secrets.Seal(passphrase(), data)
+		if err != nil {
+			return exportedConfig{}, fmt.Errorf("failed to encrypt secrets: %w", err)
+		}
+	}
+	return exported, nil
+}
+
+var configImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Apply a configuration exported with genie config export",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			color.Red("Failed to read %s: %v", args[0], err)
+			os.Exit(1)
+		}
+
+		var imported exportedConfig
+		if err := json.Unmarshal(data, &imported); err != nil {
+			color.Red("Failed to parse %s: %v", args[0], err)
+			os.Exit(1)
+		}
+		if err := importConfig(imported, func() string { return readConfigPassphrase(false) }); err != nil {
+			color.Red("%v", err)
+			os.Exit(1)
+		}
+
+		if caveat := secrets.Caveat(); caveat != "" && len(imported.Secrets) > 0 {
+			color.Yellow("⚠️  %s", caveat)
+		}
+		if len(imported.Redacted) > 0 {
+			color.Yellow("\nThese API keys were not part of the file, set them with genie init --non-interactive or genie reset:")
+			for _, key := range imported.Redacted {
+				fmt.Printf("  • %s\n", key)
+			}
+		}
+	},
+}
+
+// importConfig stores the profiles, settings and secrets of an exported
+// configuration. Like the export, it writes to the store itself and never
+// to the active profile.
+func importConfig(imported exportedConfig, passphrase func() string) error {
+	if imported.Version != 1 {
+		return fmt.Errorf("unsupported configuration version %d", imported.Version)
+	}
+
+	values := map[string]string{}
+	for key, value := range imported.Settings {
+		values[key] = value
+	}
+	if len(imported.Secrets) > 0 {
+		plain, err := secrets.Open(passphrase(), imported.Secrets)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(plain, &values); err != nil {
+			return fmt.Errorf("failed to parse secrets: %w", err)
+		}
+	}
+
+	for _, profile := range imported.Profiles {
+		if err := config.SaveProfile(profile); err != nil {
+			return fmt.Errorf("failed to import profile %s: %w", profile.Name, err)
+		}
+		color.Green("✓ profile %s", profile.Name)
+	}
+
+	// Apply known settings in table order, then profile key entries
+	for _, s := range settings {
+		value, ok := values[s.key]
+		if !ok {
+			continue
+		}
+		delete(values, s.key)
+		value, err := normalizeSetting(s.key, value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", s.flag, err)
+		}
+		if err := secrets.SetStored(s.key, value); err != nil {
+			return fmt.Errorf("failed to store %s: %w", s.key, err)
+		}
+		printStored(s, value)
+	}
+	for key, value := range values {
+		if !strings.HasPrefix(key, "profile.") {
+			color.Yellow("Skipping unknown setting %s", key)
+			continue
+		}
+		if err := secrets.SetStored(key, value); err != nil {
+			return fmt.Errorf("failed to store %s: %w", key, err)
+		}
+		color.Green("✓ %s", key)
+	}
+	return nil
+}
+
+// storeSetting validates and stores a setting, printing what was stored.
+func storeSetting(s setting, value string) error {
+	value, err := normalizeSetting(s.key, value)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", s.flag, err)
+	}
+	if err := secrets.Set(s.key, value); err != nil {
+		return fmt.Errorf("failed to store %s: %w", s.key, err)
+	}
+	printStored(s, value)
+	return nil
+}
+
+// printStored shows a stored setting, masking secrets.
+func printStored(s setting, value string) {
+	shown := value
+	if s.secret {
+		shown = maskKey(value)
+	}
+	fmt.Printf("%s %s: %s\n", color.GreenString("✓"), s.key, shown)
+}
+
+// readConfigPassphrase returns the passphrase from --passphrase, then
+// GENIE_CONFIG_PASSPHRASE, then asks for it. The prompts go to stderr, so that
+// an export to stdout stays valid JSON, and the answer is not echoed.
+func readConfigPassphrase(confirm bool) string {
+	if configPassphrase != "" {
+		return configPassphrase
+	}
+	if passphrase := os.Getenv(configPassphraseEnvVar); passphrase != "" {
+		return passphrase
+	}
+
+	passphrase := readPassphrase("Passphrase for the secrets: ")
+	if confirm && readPassphrase("Repeat the passphrase: ") != passphrase {
+		fmt.Fprintln(os.Stderr, color.RedString("The passphrases do not match."))
+		os.Exit(1)
+	}
+	return passphrase
+}
+
+// readPassphrase prompts on stderr and reads a line without echoing it when
+// stdin is a terminal.
+func readPassphrase(prompt string) string {
+	fmt.Fprint(os.Stderr, color.HiBlackString(prompt))
+	if !stdinIsTerminal() {
+		return readLine()
+	}
+	passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, color.RedString("Failed to read the passphrase: %v", err))
+		os.Exit(1)
+	}
+	return string(passphrase)
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/harshalranjhani/genie/internal/config"
+)
+
+// setupConfig stores a global key, a setting and a profile with its own key,
+// and shadows them with environment variables that must not be exported.
+func setupConfig(t *testing.T) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GENIE_PROFILE", "")
+	useMemoryStore(map[string]string{
+		openAIKeyName:         "sk-global",
+		ignoreListPathKeyName: "/home/me/.genieignore",
+		config.ProfileKeyEntry("work", openAIKeyName): "sk-work",
+	})
+	profile := config.Profile{Name: "work", Engine: "GPT", Keys: map[string]string{openAIKeyName: config.ProfileKeyEntry("work", openAIKeyName)}}
+	if err := config.SaveProfile(profile); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SetActiveProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GENIE_OPENAI_API_KEY", "sk-env")
+	t.Setenv("GENIE_IGNORE_LIST_PATH", "/env/.genieignore")
+}
+
+func TestConfigExportRedacted(t *testing.T) {
+	setupConfig(t)
+	exported, err := exportConfig(false, func() string {
+		t.Fatal("asked for a passphrase without secrets")
+		return ""
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exported.Settings[ignoreListPathKeyName] != "/home/me/.genieignore" {
+		t.Errorf("settings are %v, want the stored ignore list", exported.Settings)
+	}
+	want := []string{openAIKeyName, config.ProfileKeyEntry("work", openAIKeyName)}
+	if !slices.Equal(exported.Redacted, want) || exported.Secrets != nil {
+		t.Errorf("redacted %v with secrets %s, want %v only", exported.Redacted, exported.Secrets, want)
+	}
+	data, _ := json.Marshal(exported)
+	if strings.Contains(string(data), "sk-") || strings.Contains(string(data), "/env/") {
+		t.Errorf("the export leaks a secret or an environment value: %s", data)
+	}
+}
+
+func TestConfigRoundTrip(t *testing.T) {
+	setupConfig(t)
+	exported, err := exportConfig(true, func() string { return "correct horse" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "sk-") {
+		t.Fatalf("the export contains a key in plain text: %s", data)
+	}
+
+	// Import on a fresh machine
+	t.Setenv("HOME", t.TempDir())
+	store := useMemoryStore(nil)
+	var imported exportedConfig
+	if err := json.Unmarshal(data, &imported); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := importConfig(imported, func() string { return "wrong horse" }); err == nil {
+		t.Error("importing with the wrong passphrase succeeded")
+	}
+	if len(store.values) > 0 {
+		t.Errorf("a failed import stored %v", store.values)
+	}
+
+	if err := importConfig(imported, func() string { return "correct horse" }); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{
+		openAIKeyName:         "sk-global",
+		ignoreListPathKeyName: "/home/me/.genieignore",
+		config.ProfileKeyEntry("work", openAIKeyName): "sk-work",
+	} {
+		if got := store.values[key]; got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if profile, ok, err := config.GetProfile("work"); err != nil || !ok || profile.Engine != "GPT" {
+		t.Errorf("profile work = %+v, %v, %v", profile, ok, err)
+	}
+}
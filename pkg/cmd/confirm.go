@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/chzyer/readline"
+	"github.com/fatih/color"
+	"github.com/harshalranjhani/genie/internal/helpers"
+	"github.com/harshalranjhani/genie/internal/helpers/shell"
+)
+
+// commandEnvironment detects the shell commands are run with and makes the
+// helpers run them there. In safe mode, a shell whose commands cannot be
+// parsed is replaced by sh, so that what runs is what the policy checked.
+func commandEnvironment(safe bool) shell.Environment {
+	env := shell.DetectEnvironment()
+	if safe && !shell.Parsable(env.Shell) {
+		color.Yellow("Safe mode cannot check %s commands, so commands are written for and run with sh instead.", env.Shell)
+		env = env.WithShell("sh", "safe mode")
+	}
+	helpers.SetCommandShell(env.ShellPath)
+	return env
+}
+
+// stdinIsTerminal reports whether someone can answer a prompt on stdin.
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// printProposal shows a command before it is run, with what it does and how
+// risky it is.
+func printProposal(command, explanation string, assessment shell.Assessment) {
+	fmt.Println(strings.Repeat("─", 50))
+	color.Cyan("📋 Proposed command:")
+	fmt.Printf("  %s\n", color.HiWhiteString(command))
+	if explanation != "" {
+		color.Cyan("💡 Explanation:")
+		fmt.Printf("  %s\n", explanation)
+	}
+
+	label := fmt.Sprintf("⚠️  Risk: %s", assessment.Risk)
+	if len(assessment.Reasons) > 0 {
+		label += " (" + strings.Join(assessment.Reasons, ", ") + ")"
+	}
+	switch assessment.Risk {
+	case shell.RiskHigh:
+		color.Red(label)
+	case shell.RiskMedium:
+		color.Yellow(label)
+	default:
+		color.Green(label)
+	}
+	fmt.Println(strings.Repeat("─", 50))
+}
+
+// printFindings reports what the shell policy found in a command.
+func printFindings(findings []shell.Finding) {
+	for _, finding := range findings {
+		if finding.Action == shell.ActionBlock {
+			color.Red("🛑 Blocked by %s: %s", finding.Check, finding.Reason)
+		} else {
+			color.Yellow("⚠️  Warning from %s: %s", finding.Check, finding.Reason)
+		}
+	}
+}
+
+// confirmCommand shows the proposal and asks whether to run it, letting the
+// user edit the command first. The command is read as one for shellName, and
+// with a policy, the command, and every edit of it, is checked against it
+// first. It returns the command to run, or false when the user declined, the
+// policy blocked it or nobody is there to answer.
+func confirmCommand(command, explanation, shellName string, assumeYes bool, policy *shell.Policy) (string, bool) {
+	for {
+		assessment := shell.Assess(command, shellName)
+		printProposal(command, explanation, assessment)
+
+		if policy != nil {
+			cwd, _ := os.Getwd()
+			findings := shell.Analyze(command, shellName, policy, cwd)
+			printFindings(findings)
+			if shell.Blocked(findings) {
+				color.Red("Not running the command. Edit %s to change what safe mode blocks.", policy.Path)
+				return "", false
+			}
+		}
+
+		if assumeYes {
+			return command, true
+		}
+		if !stdinIsTerminal() {
+			color.Red("Not running the command: stdin is not a terminal. Pass --yes to run without confirmation.")
+			return "", false
+		}
+
+		fmt.Print(color.HiCyanString("Run it? [y]es / [n]o / [e]dit: "))
+		switch strings.ToLower(strings.TrimSpace(readLine())) {
+		case "y", "yes":
+			return command, true
+		case "e", "edit":
+			edited, err := editCommand(command)
+			if err != nil {
+				color.Red("Failed to edit the command: %v", err)
+				continue
+			}
+			if edited == "" {
+				color.Yellow("The command is empty, nothing to run.")
+				return "", false
+			}
+			if edited != command {
+				// The explanation described the original command
+				command, explanation = edited, ""
+			}
+		default:
+			color.Yellow("Cancelled. Nothing was run.")
+			return "", false
+		}
+	}
+}
+
+// printCommand writes a proposed command to out instead of running it, for
+// genie do --print. The proposal goes to stderr, and with a policy, a blocked
+// command is not written at all.
+func printCommand(out io.Writer, command, explanation, shellName string, policy *shell.Policy, cwd string) bool {
+	printProposal(command, explanation, shell.Assess(command, shellName))
+	if policy != nil {
+		findings := shell.Analyze(command, shellName, policy, cwd)
+		printFindings(findings)
+		if shell.Blocked(findings) {
+			color.Red("Not printing the command. Edit %s to change what safe mode blocks.", policy.Path)
+			return false
+		}
+	}
+	fmt.Fprintln(out, command)
+	return true
+}
+
+// editCommand opens the command in $VISUAL or $EDITOR, or else in a line
+// editor prefilled with the command.
+func editCommand(command string) (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		rl, err := readline.NewEx(&readline.Config{Prompt: color.HiCyanString("edit> ")})
+		if err != nil {
+			return "", err
+		}
+		defer rl.Close()
+		rl.WriteStdin([]byte(command))
+		line, err := rl.Readline()
+		return strings.TrimSpace(line), err
+	}
+
+	file, err := os.CreateTemp("", "genie-command-*.sh")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(command + "\n"); err != nil {
+		file.Close()
+		return "", err
+	}
+	file.Close()
+
+	// The editor may come with arguments, e.g. "code --wait"
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", file.Name())
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
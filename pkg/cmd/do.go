@@ -1,22 +1,29 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"strings"
 
 	"github.com/fatih/color"
-	"github.com/harshalranjhani/genie/internal/config"
 	"github.com/harshalranjhani/genie/internal/helpers"
+	"github.com/harshalranjhani/genie/internal/helpers/history"
 	"github.com/harshalranjhani/genie/internal/helpers/llm"
+	"github.com/harshalranjhani/genie/internal/helpers/shell"
 	"github.com/harshalranjhani/genie/pkg/prompts"
 	"github.com/spf13/cobra"
-	"github.com/zalando/go-keyring"
 )
 
 func init() {
 	rootCmd.AddCommand(doCmd)
 	doCmd.PersistentFlags().Bool("safe", false, "Set this to true if you wish to enable safe mode.")
+	doCmd.Flags().BoolP("yes", "y", false, "Run the proposed command without asking for confirmation")
+	doCmd.Flags().Bool("dry-run", false, "Run the command on a temporary copy of the directory first and show what it changed")
+	doCmd.Flags().Bool("agent", false, "Work through the task in several steps, feeding each command's output back to the genie")
+	doCmd.Flags().Int("max-steps", 10, "Most commands --agent runs before it stops")
+	doCmd.Flags().Bool("print", false, "Print the proposed command to stdout instead of running it, e.g. for the shell-init widget")
 }
 
 var doCmd = &cobra.Command{
@@ -29,7 +36,31 @@ var doCmd = &cobra.Command{
 			return
 		}
 
-		safeSettings, _ := cmd.Flags().GetBool("safe")
+		agent, _ := cmd.Flags().GetBool("agent")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		maxSteps, _ := cmd.Flags().GetInt("max-steps")
+		printOnly, _ := cmd.Flags().GetBool("print")
+		assumeYes, _ := cmd.Flags().GetBool("yes")
+		if agent && dryRun {
+			color.Red("--dry-run cannot be combined with --agent")
+			os.Exit(1)
+		}
+		if printOnly && (agent || dryRun || assumeYes) {
+			color.Red("--print cannot be combined with --agent, --dry-run or --yes")
+			os.Exit(1)
+		}
+		stdout := os.Stdout
+		if printOnly {
+			// Keep stdout for the command alone, so that it can be captured
+			os.Stdout = os.Stderr
+			color.Output = os.Stderr
+		}
+		if maxSteps < 1 {
+			color.Red("--max-steps must be at least 1")
+			os.Exit(1)
+		}
+
+		safeSettings := safeModeFlag(cmd)
 		dir, err := os.Getwd()
 		if err != nil {
 			log.Fatal(err)
@@ -40,60 +71,117 @@ var doCmd = &cobra.Command{
 			log.Fatal(err)
 			os.Exit(1)
 		}
-		var sb strings.Builder
-		helpers.PrintData(&sb, rootDir, 0)
-		prompt := prompts.GetDoPrompt(sb, args[0])
 
-		engineName, err := keyring.Get(serviceName, "engineName")
+		provider, engine, err := getProvider()
 		if err != nil {
-			log.Fatal("Error retrieving engine name from keyring:", err)
+			log.Fatal(err)
 		}
 
-		engine, exists := config.CheckAndGetEngine(engineName)
-		if !exists {
-			log.Fatal("Unknown engine name: ", engineName)
+		env := commandEnvironment(safeSettings)
+		environment := env.Describe()
+
+		budget := contextBudget(provider, prompts.GetDoPrompt(strings.Builder{}, args[0], environment))
+		if agent {
+			// Leave room for the output of the steps
+			budget = budget / 2
 		}
+		snapshot, notes := helpers.RenderDirectory(rootDir, budget)
+		reportTrimmed(notes)
 
+		var sb strings.Builder
+		sb.WriteString(snapshot)
+		prompt := prompts.GetDoPrompt(sb, args[0], environment)
+
+		var policy *shell.Policy
+		opts := llm.Options{SafeMode: safeSettings, Caution: prompts.CommandCaution}
 		if safeSettings {
 			color.Green("Safety settings are on.")
 			if !engine.Features.SupportsSafeMode {
-				color.Red("Currently %s does not support safe mode. But we're still instructing it to be extra cautious for this particular request.", engineName)
-				prompt += " Please ensure the command is safe and does not contain any destructive behavior like deleting files, directories, etc. If it does, please reject it and just echo why you rejected"
+				color.Red("Currently %s does not support safe mode. But we're still instructing it to be extra cautious for this particular request, and the command is checked against your shell policy.", engine.Name)
+			}
+			policy, err = shell.LoadPolicy()
+			if err != nil {
+				color.Red("Failed to load the shell policy: %v", err)
+				os.Exit(1)
 			}
 		} else {
 			color.Red("Safety settings are off.")
 		}
 
-		switch engineName {
-		case config.GPTEngine:
-			err := llm.GetGPTCmdResponse(prompt, true)
-			if err != nil {
-				log.Fatal(err)
-			}
-		case config.GeminiEngine:
-			err := llm.GetGeminiCmdResponse(prompt, safeSettings)
-			if err != nil {
-				log.Fatal(err)
-			}
-		case config.DeepSeekEngine:
-			err := llm.GetDeepSeekCmdResponse(prompt, safeSettings)
-			if err != nil {
-				log.Fatal(err)
-			}
-		case config.OllamaEngine:
-			model, err := keyring.Get(serviceName, modelAccountKey)
-			if err != nil {
-				log.Fatal("Error retrieving model name from keyring: ", err)
+		// Engine and model are filled in once an engine answered, which may be
+		// a fallback engine
+		record := history.Entry{Prompt: args[0], Cwd: dir}
+		if agent {
+			runAgent(args[0], agentRun{
+				provider:    provider,
+				snapshot:    snapshot,
+				environment: environment,
+				shell:       env.Shell,
+				opts:        opts,
+				policy:      policy,
+				assumeYes:   assumeYes,
+				maxSteps:    maxSteps,
+				record:      record,
+			})
+			return
+		}
+
+		var proposal doProposal
+		s := newSpinner("Analyzing: ")
+		ctx, cancel := requestContext()
+		err = llm.CompleteJSON(ctx, provider, prompt, doSchema, opts, &proposal, proposal.validate)
+		cancel()
+		s.Stop()
+		if errors.Is(err, llm.ErrUnsafeContent) {
+			fmt.Println("The generated command contains inappropriate content.")
+			os.Exit(1)
+		}
+		if errors.Is(err, llm.ErrInvalidJSON) {
+			color.Red("The genie did not propose a usable command: %v", err)
+			os.Exit(1)
+		}
+		if err != nil {
+			exitIfCancelled(err)
+			log.Fatal(err)
+		}
+		reportFallback(provider)
+		record.Engine, record.Model = provider.Name(), provider.Model()
+
+		command, explanation := proposal.Command, proposal.Explanation
+		printProposalNotes(proposal, env.Shell)
+
+		if printOnly {
+			record.Command, record.Explanation = command, explanation
+			recordHistory(record)
+			if !printCommand(stdout, command, explanation, env.Shell, policy, dir) {
+				os.Exit(1)
 			}
-			err = llm.GetOllamaCmdResponse(prompt, model, safeSettings)
-			if err != nil {
-				log.Fatal(err)
+			return
+		}
+
+		if dryRun {
+			if !dryRunCommand(command, dir, env, policy, assumeYes) {
+				os.Exit(1)
 			}
+			// The point of a dry run is to decide afterwards
+			assumeYes = false
 		}
 
+		record.Command, record.Explanation = command, explanation
+		command, ok := confirmCommand(command, explanation, env.Shell, assumeYes, policy)
+		if !ok {
+			recordHistory(record)
+			os.Exit(1)
+		}
+		if command != record.Command {
+			record.Command, record.Explanation = command, ""
+		}
+
+		fmt.Println("Running the command: ", command)
+		_, exitCode, err := runRecorded(record)
 		if err != nil {
 			log.Fatal(err)
 		}
-
+		os.Exit(exitCode)
 	},
 }
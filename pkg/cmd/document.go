@@ -1,17 +1,19 @@
 package cmd
 
 import (
-	"fmt"
+	"context"
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/briandowns/spinner"
 	"github.com/fatih/color"
-	"github.com/harshalranjhani/genie/internal/config"
-	"github.com/harshalranjhani/genie/internal/helpers"
 	"github.com/harshalranjhani/genie/internal/helpers/llm"
+	"github.com/harshalranjhani/genie/pkg/prompts"
 	"github.com/spf13/cobra"
-	"github.com/zalando/go-keyring"
 )
 
 var filePathToConnect string
@@ -36,46 +38,54 @@ var documentCmd = &cobra.Command{
 			filePath = filepath.Join(cwd, filePathToConnect)
 		}
 
-		engineName, err := keyring.Get(serviceName, "engineName")
+		provider, engine, err := getProvider()
 		if err != nil {
-			log.Fatal("Error retrieving engine name from keyring:", err)
-		}
-
-		engine, exists := config.CheckAndGetEngine(engineName)
-		if !exists {
-			log.Fatal("Unknown engine name: ", engineName)
+			log.Fatal(err)
 		}
 
 		if !engine.Features.SupportsDocumentation {
-			color.Yellow("%s engine does not support documentation generation yet. Check back soon!", engineName)
+			color.Yellow("%s engine does not support documentation generation yet. Check back soon!", engine.Name)
 			return
 		}
 
-		switch engineName {
-		case config.GPTEngine:
-			err := llm.DocumentCodeWithGPT(filePath)
-			if err != nil {
-				log.Fatalf("Failed to document code: %v", err)
-			}
-			color.Green("Code documented successfully!")
-			pathToOpen := fmt.Sprintf("code %s", filePath)
-			helpers.RunCommand(pathToOpen)
-		case config.DeepSeekEngine:
-			err := llm.DocumentCodeWithDeepSeek(filePath)
-			if err != nil {
-				log.Fatalf("Failed to document code: %v", err)
-			}
-			color.Green("Code documented successfully!")
-		case config.OllamaEngine:
-			model, err := keyring.Get(serviceName, modelAccountKey)
-			if err != nil {
-				log.Fatal("Error retrieving model name from keyring: ", err)
-			}
-			err = llm.DocumentCodeWithOllama(filePath, model)
-			if err != nil {
-				log.Fatalf("Failed to document code: %v", err)
-			}
-			color.Green("Code documented successfully!")
+		ctx, cancel := requestContext()
+		defer cancel()
+
+		if err := documentCode(ctx, provider, filePath); err != nil {
+			exitIfCancelled(err)
+			log.Fatalf("Failed to document code: %v", err)
 		}
+		reportFallback(provider)
+		color.Green("Code documented successfully!")
 	},
 }
+
+func documentCode(ctx context.Context, provider llm.Provider, filePath string) error {
+	s := spinner.New(spinner.CharSets[11], 100*time.Millisecond)
+	s.Prefix = color.HiCyanString("Analyzing code: ")
+	s.Start()
+	defer s.Stop()
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	prompt := prompts.GetDocumentPrompt(string(content))
+
+	documentedContent, err := provider.Complete(ctx, prompt, llm.Options{
+		System: "You are a helpful assistant who documents code.",
+	})
+	if err != nil {
+		return err
+	}
+
+	// Extract code from markdown code blocks if present
+	re := regexp.MustCompile("(?s)```.*?\n(.*?)\n```")
+	matches := re.FindStringSubmatch(documentedContent)
+	if len(matches) > 1 {
+		documentedContent = matches[1]
+	}
+
+	return os.WriteFile(filePath, []byte(strings.TrimSpace(documentedContent)), 0644)
+}
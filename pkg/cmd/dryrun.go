@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/harshalranjhani/genie/internal/helpers"
+	"github.com/harshalranjhani/genie/internal/helpers/shell"
+)
+
+// dryRunCommand runs command with the shell of env against a copy of dir and shows
+// its output and the files it changed. Commands the policy blocks are not run
+// at all, and commands that reach outside the copy are only run once the user
+// agrees. It reports whether the dry run happened.
+func dryRunCommand(command, dir string, env shell.Environment, policy *shell.Policy, assumeYes bool) bool {
+	color.Cyan("🧪 Dry run of: %s", command)
+
+	if policy != nil {
+		findings := shell.Analyze(command, env.Shell, policy, dir)
+		printFindings(findings)
+		if shell.Blocked(findings) {
+			color.Red("Not running the command, not even in the sandbox. Edit %s to change what safe mode blocks.", policy.Path)
+			return false
+		}
+	}
+
+	if escapes := shell.Escapes(command, env.Shell); len(escapes) > 0 {
+		color.Yellow("The command reaches outside the sandbox, these effects are real even in a dry run:")
+		for _, escape := range escapes {
+			color.Yellow("  • %s", escape)
+		}
+		if !assumeYes {
+			if !stdinIsTerminal() {
+				color.Red("Not starting the dry run: stdin is not a terminal. Pass --yes to start it anyway.")
+				return false
+			}
+			fmt.Print(color.HiCyanString("Start the dry run anyway? [y/N]: "))
+			if answer := strings.ToLower(strings.TrimSpace(readLine())); answer != "y" && answer != "yes" {
+				color.Yellow("Cancelled. Nothing was run.")
+				return false
+			}
+		}
+	}
+
+	ignorePath, patterns, err := helpers.IgnorePatterns()
+	if err != nil {
+		color.Red("%v", err)
+		return false
+	}
+
+	s := newSpinner("Running in a sandbox: ")
+	ctx, cancel := requestContext()
+	result, err := shell.DryRun(ctx, env.ShellPath, command, dir, func(path string) bool {
+		return helpers.ShouldIgnore(path, patterns)
+	})
+	cancel()
+	s.Stop()
+	if err != nil {
+		exitIfCancelled(err)
+		color.Red("Dry run failed: %v", err)
+		if errors.Is(err, shell.ErrSandboxTooLarge) {
+			color.Yellow("Add large folders such as node_modules to your ignore list (%s), or start the dry run from a smaller directory.", ignorePath)
+		}
+		return false
+	}
+
+	printDryRun(result)
+	return true
+}
+
+func printDryRun(result *shell.DryRunResult) {
+	fmt.Println(strings.Repeat("─", 50))
+	if result.Stdout != "" {
+		color.Cyan("Output:")
+		fmt.Print(indent(result.Stdout))
+	}
+	if result.Stderr != "" {
+		color.Cyan("Errors:")
+		fmt.Print(color.RedString(indent(result.Stderr)))
+	}
+	if result.ExitCode == 0 {
+		color.Green("Exit code: 0")
+	} else {
+		color.Red("Exit code: %d", result.ExitCode)
+	}
+
+	color.Cyan("Filesystem changes:")
+	if len(result.Changes) == 0 {
+		fmt.Println("  • none")
+	}
+	for _, change := range result.Changes {
+		switch change.Kind {
+		case "created":
+			color.Green("  + %s (%s)", change.Path, formatSize(change.SizeAfter))
+		case "deleted":
+			color.Red("  - %s (%s)", change.Path, formatSize(change.SizeBefore))
+		default:
+			color.Yellow("  ~ %s (%s → %s)", change.Path, formatSize(change.SizeBefore), formatSize(change.SizeAfter))
+		}
+	}
+	if result.Skipped > 0 {
+		color.HiBlack("%d ignored paths were not copied into the sandbox.", result.Skipped)
+	}
+	if len(result.SkippedLinks) > 0 {
+		color.Yellow("Symlinks that point outside the directory were left out of the sandbox:")
+		for _, link := range result.SkippedLinks {
+			color.Yellow("  • %s", link)
+		}
+	}
+	fmt.Println(strings.Repeat("─", 50))
+}
+
+func indent(text string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	return "  " + strings.Join(lines, "\n  ") + "\n"
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
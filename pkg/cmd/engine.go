@@ -1,13 +1,13 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/fatih/color"
-	"github.com/harshalranjhani/genie/internal/config"
+	"github.com/harshalranjhani/genie/internal/secrets"
 	"github.com/spf13/cobra"
-	"github.com/zalando/go-keyring"
 )
 
 func init() {
@@ -21,21 +21,28 @@ var engineCmd = &cobra.Command{
 It shows which engine is active (GPT or Gemini) and which specific model is being used.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Get current engine
-		engineName, err := keyring.Get(serviceName, "engineName")
+		engineName, err := currentEngineName()
 		if err != nil {
-			color.Red("No engine configured. Please run `genie init` to set up your configuration.")
+			if errors.Is(err, secrets.ErrNotFound) {
+				color.Red("No engine configured. Please run `genie init` to set up your configuration.")
+			} else {
+				color.Red("%v", err)
+			}
 			return
 		}
 
 		// Get current model
-		modelName, err := keyring.Get(serviceName, modelAccountKey)
-		if err != nil {
+		modelName := currentModelName(engineName)
+		if modelName == "" {
 			modelName = "default" // Fallback if no model is explicitly set
 		}
 
 		// Print configuration details
 		fmt.Println(color.HiMagentaString("🧞 Current Configuration"))
 		fmt.Println(strings.Repeat("─", 50))
+		if selectionOverridden() {
+			color.Yellow("Overridden for this command by --engine/--model or %s/%s", engineEnvVar, modelEnvVar)
+		}
 
 		// Print engine info
 		color.Cyan("Engine:")
@@ -47,38 +54,7 @@ It shows which engine is active (GPT or Gemini) and which specific model is bein
 
 		// Print available models for current engine
 		color.Cyan("\nAvailable Models:")
-		if engineName == config.OllamaEngine {
-			models, err := getRunningOllamaModels()
-			if err != nil {
-				color.Red("Error: %v", err)
-				return
-			}
-			if len(models) == 0 {
-				color.Yellow("  No running Ollama models found. Please start some models first.")
-				return
-			}
-			for _, model := range models {
-				if model == modelName {
-					color.Green("  • %s (current)", model)
-				} else {
-					fmt.Printf("  • %s\n", model)
-				}
-			}
-		} else {
-			engine, exists := config.CheckAndGetEngine(engineName)
-			if !exists {
-				color.Yellow("  No models available for engine: %s", engineName)
-				return
-			}
-
-			for _, model := range engine.Models {
-				if model == modelName {
-					color.Green("  • %s (current)", model)
-				} else {
-					fmt.Printf("  • %s\n", model)
-				}
-			}
-		}
+		printModelList(availableModels(engineName, false), modelName)
 
 		// Print helpful commands
 		fmt.Println(strings.Repeat("─", 50))
@@ -0,0 +1,278 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/harshalranjhani/genie/internal/helpers/llm"
+	"github.com/harshalranjhani/genie/internal/helpers/shell"
+	"github.com/harshalranjhani/genie/internal/helpers/usage"
+	"github.com/harshalranjhani/genie/pkg/prompts"
+	"github.com/spf13/cobra"
+)
+
+// maxTokenWidth caps the column of words in the explanation tree, so one long
+// argument does not push every explanation off the screen.
+const maxTokenWidth = 24
+
+var explainFile string
+
+// annotationPattern matches the "<id>: <explanation>" lines of the engine.
+var annotationPattern = regexp.MustCompile(`^\s*([0-9]+(?:\.[0-9]+)*(?:\.effects)?|summary)\s*:\s*(.+)$`)
+
+func init() {
+	explainCmd.Flags().StringVarP(&explainFile, "file", "f", "", "Explain a whole script, statement by statement")
+	rootCmd.AddCommand(explainCmd)
+}
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <command>",
+	Short: "Break a shell command down and explain every part of it",
+	Long: `Split a command line into its pipeline stages, flags and arguments and let the genie explain each of them, with side effects and risk.
+Example: genie explain "find . -name '*.log' -mtime +7 | xargs gzip -9"
+         genie explain --file deploy.sh`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var script string
+		switch {
+		case explainFile != "" && len(args) > 0:
+			color.Red("Pass either a command or --file, not both")
+			os.Exit(1)
+		case explainFile != "":
+			data, err := os.ReadFile(explainFile)
+			if err != nil {
+				color.Red("Failed to read %s: %v", explainFile, err)
+				os.Exit(1)
+			}
+			script = string(data)
+		case len(args) == 0:
+			color.Red("Please provide a command to explain, or --file with a script")
+			os.Exit(1)
+		default:
+			script = strings.Join(args, " ")
+		}
+
+		env := shell.DetectEnvironment()
+		shellName := env.Shell
+		if !shell.Parsable(shellName) {
+			color.Yellow("%s commands cannot be parsed, reading the command as bash instead.", shellName)
+			shellName = "bash"
+		}
+		statements, err := shell.Breakdown(script, shellName)
+		if err != nil {
+			color.Red("Failed to parse the command: %v", err)
+			os.Exit(1)
+		}
+		if len(statements) == 0 {
+			color.Yellow("There is nothing to explain.")
+			return
+		}
+
+		provider, _, err := getProvider()
+		if err != nil {
+			log.Fatal(err)
+		}
+		// Long scripts are explained as far as they fit into the context
+		// budget; the risk checks below still cover all of it
+		all := len(statements)
+		statements = fitStatements(statements, contextBudget(provider, prompts.GetExplainPrompt("", explainFile != "", env.Describe())))
+		if len(statements) == 0 {
+			color.Red("The first statement alone is too long for the context of %s. Explain a shorter part of it.", provider.Model())
+			os.Exit(1)
+		}
+		if len(statements) < all {
+			reportTrimmed([]string{fmt.Sprintf("The script is too long to explain at once: only the first %d of %d statements, up to line %d, are explained. The risk is checked for the whole script.", len(statements), all, statements[len(statements)-1].Line)})
+		}
+		prompt := prompts.GetExplainPrompt(explainListing(statements), explainFile != "", env.Describe())
+
+		s := newSpinner("Explaining: ")
+		ctx, cancel := requestContext()
+		response, err := provider.Complete(ctx, prompt, llm.Options{})
+		cancel()
+		s.Stop()
+		if err != nil {
+			exitIfCancelled(err)
+			log.Fatal(err)
+		}
+		reportFallback(provider)
+
+		notes := map[string]string{}
+		for _, line := range strings.Split(response, "\n") {
+			if match := annotationPattern.FindStringSubmatch(line); match != nil {
+				notes[match[1]] = strings.TrimSpace(match[2])
+			}
+		}
+
+		policy, err := shell.LoadPolicy()
+		if err != nil {
+			color.Yellow("Using the default shell policy: %v", err)
+			policy = shell.DefaultPolicy()
+		}
+		cwd, _ := os.Getwd()
+
+		if summary := notes["summary"]; summary != "" {
+			color.HiMagenta("📖 %s", explainFile)
+			fmt.Println(summary)
+		}
+		multiple := explainFile != "" || all > 1
+		for i, statement := range statements {
+			printExplanation(statementID(i), statement, shellName, notes, multiple)
+		}
+		// A statement can depend on the ones before it, e.g. rm -rf * after
+		// cd /, so the policy checks look at the whole script
+		if multiple {
+			fmt.Println(strings.Repeat("─", 50))
+			color.HiMagenta("📖 The whole script")
+		}
+		printRisk(shell.Assess(script, shellName))
+		printPolicyVerdicts(script, shellName, policy, cwd)
+	},
+}
+
+// fitStatements returns the statements, from the start, whose listing fits
+// into budget tokens.
+func fitStatements(statements []shell.Statement, budget int) []shell.Statement {
+	tokens := 0
+	for i, statement := range statements {
+		tokens += usage.EstimateTokens(explainListing([]shell.Statement{statement}))
+		if tokens > budget {
+			return statements[:i]
+		}
+	}
+	return statements
+}
+
+// statementID numbers statements from 1. Stages and words add to it, e.g.
+// 1.2.3 is the third word of the second stage of the first statement.
+func statementID(index int) string {
+	return fmt.Sprint(index + 1)
+}
+
+// explainListing lists every statement, stage and word with its id.
+func explainListing(statements []shell.Statement) string {
+	var sb strings.Builder
+	for i, statement := range statements {
+		id := statementID(i)
+		fmt.Fprintf(&sb, "%s statement (line %d): %s\n", id, statement.Line, oneLine(statement.Text))
+		for j, stage := range statement.Stages {
+			stageID := fmt.Sprintf("%s.%d", id, j+1)
+			fmt.Fprintf(&sb, "  %s stage: %s\n", stageID, oneLine(stage.Text))
+			for k, token := range stage.Tokens {
+				fmt.Fprintf(&sb, "    %s.%d %s: %s\n", stageID, k+1, token.Kind, oneLine(token.Text))
+			}
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// printExplanation shows the tree of a statement. For a statement of a
+// script, it also shows the risk of that statement on its own.
+func printExplanation(id string, statement shell.Statement, shellName string, notes map[string]string, script bool) {
+	fmt.Println(strings.Repeat("─", 50))
+	if script {
+		color.HiMagenta("📖 Line %d: %s", statement.Line, oneLine(statement.Text))
+	} else {
+		color.HiMagenta("📖 %s", oneLine(statement.Text))
+	}
+	if note := notes[id]; note != "" {
+		fmt.Println("  " + note)
+	}
+
+	for j, stage := range statement.Stages {
+		stageID := fmt.Sprintf("%s.%d", id, j+1)
+		branch, rail := "├─", "│ "
+		if j == len(statement.Stages)-1 {
+			branch, rail = "└─", "  "
+		}
+		fmt.Printf("  %s %s", branch, color.CyanString(oneLine(stage.Text)))
+		if note := notes[stageID]; note != "" {
+			fmt.Printf("  %s", color.HiBlackString("— "+note))
+		}
+		fmt.Println()
+
+		width := 0
+		for _, token := range stage.Tokens {
+			width = max(width, min(len([]rune(oneLine(token.Text))), maxTokenWidth))
+		}
+		for k, token := range stage.Tokens {
+			note := notes[fmt.Sprintf("%s.%d", stageID, k+1)]
+			text := oneLine(token.Text)
+			fmt.Printf("  %s   %s  %s\n", rail, tokenColor(token.Kind)(fmt.Sprintf("%-*s", width, text)), note)
+		}
+		if stage.Operator != "" {
+			fmt.Printf("  %s %s\n", rail, color.HiBlackString("%s %s", stage.Operator, operatorMeaning(stage.Operator)))
+		}
+	}
+
+	if effects := notes[id+".effects"]; effects != "" {
+		fmt.Printf("  %s %s\n", color.HiBlackString("Side effects:"), effects)
+	}
+	if escapes := shell.Escapes(statement.Text, shellName); len(escapes) > 0 {
+		fmt.Printf("  %s %s\n", color.HiBlackString("Outside this directory:"), strings.Join(escapes, ", "))
+	}
+	if script {
+		printRisk(shell.Assess(statement.Text, shellName))
+	}
+}
+
+func printRisk(assessment shell.Assessment) {
+	label := fmt.Sprintf("  ⚠️  Risk: %s", assessment.Risk)
+	if len(assessment.Reasons) > 0 {
+		label += " (" + strings.Join(assessment.Reasons, ", ") + ")"
+	}
+	switch assessment.Risk {
+	case shell.RiskHigh:
+		color.Red(label)
+	case shell.RiskMedium:
+		color.Yellow(label)
+	default:
+		color.Green(label)
+	}
+}
+
+// printPolicyVerdicts shows what safe mode would do with a command or a script.
+func printPolicyVerdicts(script, shellName string, policy *shell.Policy, cwd string) {
+	for _, finding := range shell.Analyze(script, shellName, policy, cwd) {
+		verdict := "safe mode would warn"
+		if finding.Action == shell.ActionBlock {
+			verdict = "safe mode would block"
+		}
+		color.HiBlack("     %s: %s (%s)", verdict, finding.Reason, finding.Check)
+	}
+}
+
+func tokenColor(kind string) func(format string, a ...interface{}) string {
+	switch kind {
+	case "command":
+		return color.HiWhiteString
+	case "flag":
+		return color.YellowString
+	case "redirect", "background":
+		return color.MagentaString
+	default:
+		return color.GreenString
+	}
+}
+
+func operatorMeaning(operator string) string {
+	switch operator {
+	case "|":
+		return "passes the output to"
+	case "|&":
+		return "passes the output and errors to"
+	case "&&":
+		return "then, if it succeeded,"
+	case "||":
+		return "then, if it failed,"
+	default:
+		return "then"
+	}
+}
+
+// oneLine collapses the whitespace of multi-line statements.
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
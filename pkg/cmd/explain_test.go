@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/harshalranjhani/genie/internal/helpers/shell"
+	"github.com/harshalranjhani/genie/internal/helpers/usage"
+)
+
+func TestFitStatements(t *testing.T) {
+	script := strings.Repeat("docker compose up --build --detach web worker\n", 20)
+	statements, err := shell.Breakdown(script, "bash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	one := usage.EstimateTokens(explainListing(statements[:1]))
+
+	tests := []struct {
+		budget int
+		want   int
+	}{
+		{one * 100, 20},
+		{one*5 + one/2, 5},
+		{one - 1, 0},
+	}
+	for _, test := range tests {
+		if got := fitStatements(statements, test.budget); len(got) != test.want {
+			t.Errorf("fitStatements with a budget of %d tokens kept %d statements, want %d", test.budget, len(got), test.want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/harshalranjhani/genie/internal/config"
+	"github.com/harshalranjhani/genie/internal/secrets"
+	"github.com/spf13/cobra"
+)
+
+// fallbackDisabled turns the fallback chain off in genie config import.
+const fallbackDisabled = "off"
+
+var (
+	fallbackChainFlag string
+	fallbackOff       bool
+)
+
+func init() {
+	fallbackCmd.Flags().StringVar(&fallbackChainFlag, "set", "", `Set the fallback chain, e.g. "Gemini -> GPT -> Ollama"`)
+	fallbackCmd.Flags().BoolVar(&fallbackOff, "off", false, "Disable falling back to other engines")
+	rootCmd.AddCommand(fallbackCmd)
+}
+
+var fallbackCmd = &cobra.Command{
+	Use:   "fallback",
+	Short: "Configure which engines answer when the current one fails",
+	Long: `When a request fails because of an invalid key, an exhausted quota, a server error or an unreachable server,
+genie retries it with the next engine of the fallback chain. The current engine is always tried first.
+Falling back sends the prompt, with its directory snapshot and attachments, to those engines, so there is no chain until you set one.
+Example: genie fallback --set "Gemini -> GPT -> Ollama"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		switch {
+		case fallbackOff:
+			if err := secrets.Delete(fallbackChainKeyName); err != nil && !errors.Is(err, secrets.ErrNotFound) {
+				color.Red("Failed to disable the fallback chain: %v", err)
+				return
+			}
+			color.Green("✓ Fallback disabled. Requests will only use the current engine.")
+		case fallbackChainFlag != "":
+			chain, err := config.ParseEngineChain(fallbackChainFlag)
+			if err != nil {
+				color.Red("Invalid fallback chain: %v", err)
+				return
+			}
+			if err := secrets.Set(fallbackChainKeyName, strings.Join(chain, ",")); err != nil {
+				color.Red("Failed to store the fallback chain: %v", err)
+				return
+			}
+			color.Green("✓ Fallback chain updated. Failed requests are now sent on to these engines.")
+		}
+
+		engineName, _ := currentEngineName()
+		fmt.Println(color.HiMagentaString("↪️  Fallback Chain"))
+		fmt.Println(strings.Repeat("─", 50))
+
+		chain := getFallbackChain(engineName)
+		if chain == nil {
+			color.Yellow("  Disabled, requests only use the current engine")
+		}
+		for i, engine := range chain {
+			if i == 0 {
+				color.Green("  %d. %s (current)", i+1, engine)
+			} else {
+				fmt.Printf("  %d. %s\n", i+1, engine)
+			}
+		}
+
+		fmt.Println(strings.Repeat("─", 50))
+		color.HiBlue("Helpful Commands:")
+		fmt.Println(`• Set chain:   genie fallback --set "Gemini -> GPT -> Ollama"`)
+		fmt.Println("• Disable:     genie fallback --off")
+	},
+}
+
+// getFallbackChain returns the engines tried in order for engineName, starting
+// with engineName itself, or nil when falling back is disabled. Falling back
+// is opt-in: without a stored chain, only engineName answers.
+func getFallbackChain(engineName string) []string {
+	engine, exists := config.CheckAndGetEngine(engineName)
+	if !exists {
+		engine = config.EngineMap[config.GeminiEngine]
+	}
+
+	stored, _ := secrets.Get(fallbackChainKeyName)
+	if stored == "" || stored == fallbackDisabled {
+		return nil
+	}
+	configured, err := config.ParseEngineChain(stored)
+	if err != nil {
+		return nil
+	}
+
+	chain := []string{engine.Name}
+	for _, name := range configured {
+		if name != engine.Name {
+			chain = append(chain, name)
+		}
+	}
+	return chain
+}
@@ -2,14 +2,10 @@ package cmd
 
 import (
 	"log"
-	"os"
 
-	"github.com/fatih/color"
-	"github.com/harshalranjhani/genie/internal/config"
 	"github.com/harshalranjhani/genie/internal/helpers/llm"
 	"github.com/harshalranjhani/genie/pkg/prompts"
 	"github.com/spf13/cobra"
-	"github.com/zalando/go-keyring"
 )
 
 func init() {
@@ -31,39 +27,26 @@ Whether you're navigating complex commands or seeking general guidance, the Geni
 			prompt = prompts.GetGreetPrompt("")
 		}
 
-		engineName, err := keyring.Get(serviceName, "engineName")
+		provider, _, err := getProvider()
 		if err != nil {
-			log.Fatal("Error retrieving engine name from keyring:", err)
+			log.Fatal(err)
 		}
 
-		_, exists := config.CheckAndGetEngine(engineName)
-		if !exists {
-			log.Fatal("Unknown engine name: ", engineName)
+		s := newSpinner("Analyzing: ")
+		ctx, cancel := requestContext()
+		defer cancel()
+
+		stream, err := provider.Stream(ctx, prompt, llm.Options{SafeMode: true})
+		s.Stop()
+		if err != nil {
+			exitIfCancelled(err)
+			log.Fatalf("Error getting response from %s: %v", provider.Name(), err)
 		}
+		reportFallback(provider)
 
-		c := color.New(color.FgRed)
-		switch engineName {
-		case config.GPTEngine:
-			llm.GetGPTGeneralResponse(prompt, false)
-		case config.GeminiEngine:
-			strResp, err := llm.GetGeminiGeneralResponse(prompt, true, false)
-			if err != nil {
-				log.Fatal("Error getting response from Gemini: ", err)
-				os.Exit(1)
-			}
-			c.Println(formatMarkdownToPlainText(strResp))
-		case config.DeepSeekEngine:
-			err := llm.GetDeepSeekGeneralResponse(prompt, true, false)
-			if err != nil {
-				log.Fatal("Error getting response from DeepSeek: ", err)
-				os.Exit(1)
-			}
-		case config.OllamaEngine:
-			model, err := keyring.Get(serviceName, modelAccountKey)
-			if err != nil {
-				log.Fatal("Error retrieving model name from keyring: ", err)
-			}
-			llm.GetOllamaGeneralResponse(prompt, model, false)
+		if _, err := printStream(stream); err != nil {
+			exitIfCancelled(err)
+			log.Fatalf("Error getting response from %s: %v", provider.Name(), err)
 		}
 	},
 }
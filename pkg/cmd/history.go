@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/harshalranjhani/genie/internal/helpers"
+	"github.com/harshalranjhani/genie/internal/helpers/history"
+	"github.com/harshalranjhani/genie/internal/helpers/shell"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historySearch string
+	historyLimit  int
+)
+
+func init() {
+	doHistoryCmd.Flags().StringVar(&historySearch, "search", "", "Only show commands whose prompt, command or directory contains this text")
+	doHistoryCmd.Flags().IntVar(&historyLimit, "limit", 20, "Number of commands to show (0 for all)")
+	doRerunCmd.Flags().BoolP("yes", "y", false, "Run the command without asking for confirmation")
+
+	doCmd.AddCommand(doHistoryCmd, doShowCmd, doRerunCmd)
+}
+
+var doHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List the commands genie do proposed, newest first",
+	Long: `Every command genie do proposes is kept in ~/.genie/history.jsonl with its prompt, engine, directory, exit code and output.
+Example: genie do history --search ffmpeg`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		entries, err := history.Load()
+		if err != nil {
+			color.Red("Failed to read the history: %v", err)
+			os.Exit(1)
+		}
+
+		var shown []history.Entry
+		for i := len(entries) - 1; i >= 0; i-- {
+			if historySearch != "" && !entries[i].Matches(historySearch) {
+				continue
+			}
+			shown = append(shown, entries[i])
+			if historyLimit > 0 && len(shown) == historyLimit {
+				break
+			}
+		}
+
+		fmt.Println(color.HiMagentaString("📜 genie do history"))
+		fmt.Println(strings.Repeat("─", 50))
+		if len(shown) == 0 {
+			color.Yellow("  No commands found.")
+		}
+		for _, entry := range shown {
+			fmt.Printf("%s  %s  %s  %s\n",
+				color.CyanString("#%-4d", entry.ID),
+				color.HiBlackString(entry.Time.Format("2006-01-02 15:04")),
+				historyStatus(entry),
+				entry.Command)
+			fmt.Printf("       %s\n", color.HiBlackString(truncate(entry.Prompt, 70)))
+		}
+		fmt.Println(strings.Repeat("─", 50))
+		if len(shown) > 0 {
+			color.HiBlack("Details: genie do show <id>   Run again: genie do rerun <id>")
+		}
+	},
+}
+
+var doShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show a command from the history with its output",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		entry := historyEntry(args[0])
+
+		fmt.Println(color.HiMagentaString("📜 genie do #%d", entry.ID))
+		fmt.Println(strings.Repeat("─", 50))
+		printHistoryField("Prompt", entry.Prompt)
+		printHistoryField("Command", entry.Command)
+		printHistoryField("Explanation", entry.Explanation)
+		printHistoryField("Engine", strings.TrimSpace(entry.Engine+" "+entry.Model))
+		printHistoryField("Directory", entry.Cwd)
+		printHistoryField("Time", entry.Time.Format("2006-01-02 15:04:05"))
+		if entry.Step > 0 {
+			printHistoryField("Agent Step", strconv.Itoa(entry.Step))
+		}
+		if entry.RerunOf > 0 {
+			printHistoryField("Rerun Of", fmt.Sprintf("#%d", entry.RerunOf))
+		}
+		color.Cyan("Result:")
+		fmt.Printf("  • %s\n", historyStatus(entry))
+		if entry.Output != "" {
+			color.Cyan("Output:")
+			fmt.Print(indent(entry.Output))
+		}
+		fmt.Println(strings.Repeat("─", 50))
+	},
+}
+
+var doRerunCmd = &cobra.Command{
+	Use:   "rerun <id>",
+	Short: "Run a command from the history again, after confirmation",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		entry := historyEntry(args[0])
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			color.Red("%v", err)
+			os.Exit(1)
+		}
+		if entry.Cwd != "" && entry.Cwd != cwd {
+			color.Yellow("This command was proposed in %s and will now run in %s.", entry.Cwd, cwd)
+		}
+
+		var policy *shell.Policy
+		if safeModeFlag(cmd) {
+			policy, err = shell.LoadPolicy()
+			if err != nil {
+				color.Red("Failed to load the shell policy: %v", err)
+				os.Exit(1)
+			}
+		}
+		env := commandEnvironment(policy != nil)
+
+		rerun := history.Entry{
+			Prompt:      entry.Prompt,
+			Engine:      entry.Engine,
+			Model:       entry.Model,
+			Explanation: entry.Explanation,
+			Cwd:         cwd,
+			RerunOf:     entry.ID,
+		}
+		assumeYes, _ := cmd.Flags().GetBool("yes")
+		command, ok := confirmCommand(entry.Command, entry.Explanation, env.Shell, assumeYes, policy)
+		rerun.Command = entry.Command
+		if !ok {
+			recordHistory(rerun)
+			os.Exit(1)
+		}
+		if command != entry.Command {
+			rerun.Command, rerun.Explanation = command, ""
+		}
+
+		fmt.Println("Running the command: ", command)
+		_, exitCode, err := runRecorded(rerun)
+		if err != nil {
+			color.Red("Failed to run the command: %v", err)
+			os.Exit(1)
+		}
+		os.Exit(exitCode)
+	},
+}
+
+// runRecorded runs entry.Command with the user's shell and adds it to the
+// history with its exit code and output, which it also returns.
+func runRecorded(entry history.Entry) (string, int, error) {
+	output, exitCode, err := helpers.RunCommandCapture(entry.Command)
+	if err != nil {
+		return output, exitCode, err
+	}
+	entry.Ran, entry.ExitCode, entry.Output = true, exitCode, output
+	recordHistory(entry)
+	return output, exitCode, nil
+}
+
+// recordHistory adds an entry to the history. Failing to do so is not worth
+// failing the command for.
+func recordHistory(entry history.Entry) {
+	if _, err := history.Record(entry); err != nil {
+		color.Yellow("Could not save the command to the history: %v", err)
+	}
+}
+
+// historyEntry returns the entry for an ID given on the command line, or
+// exits.
+func historyEntry(arg string) history.Entry {
+	id, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+	if err != nil {
+		color.Red("Invalid id %q, see genie do history", arg)
+		os.Exit(1)
+	}
+	entry, found, err := history.Get(id)
+	if err != nil {
+		color.Red("Failed to read the history: %v", err)
+		os.Exit(1)
+	}
+	if !found {
+		color.Red("No command #%d in the history.", id)
+		os.Exit(1)
+	}
+	return entry
+}
+
+// printHistoryField prints a field of an entry, skipping empty ones.
+func printHistoryField(name, value string) {
+	if value == "" {
+		return
+	}
+	color.Cyan("%s:", name)
+	fmt.Printf("  • %s\n", value)
+}
+
+func historyStatus(entry history.Entry) string {
+	switch {
+	case !entry.Ran:
+		return color.YellowString("not run")
+	case entry.ExitCode == 0:
+		return color.GreenString("exit 0")
+	default:
+		return color.RedString("exit %d", entry.ExitCode)
+	}
+}
+
+func truncate(text string, max int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-1]) + "…"
+}
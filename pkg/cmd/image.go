@@ -9,7 +9,6 @@ import (
 	"github.com/harshalranjhani/genie/internal/config"
 	"github.com/harshalranjhani/genie/internal/helpers/llm"
 	"github.com/spf13/cobra"
-	"github.com/zalando/go-keyring"
 )
 
 func init() {
@@ -26,9 +25,9 @@ var imageCmd = &cobra.Command{
 			return
 		}
 
-		engineName, err := keyring.Get(serviceName, "engineName")
+		engineName, err := currentEngineName()
 		if err != nil {
-			log.Fatal("Error retrieving engine name from keyring:", err)
+			log.Fatal(err)
 		}
 
 		engine, exists := config.CheckAndGetEngine(engineName)
@@ -43,8 +42,12 @@ var imageCmd = &cobra.Command{
 		}
 
 		prompt := args[0]
-		filePath, err := llm.GenerateGPTImage(prompt)
+		ctx, cancel := requestContext()
+		defer cancel()
+
+		filePath, err := llm.GenerateGPTImage(ctx, prompt)
 		if err != nil {
+			exitIfCancelled(err)
 			return
 		}
 		fmt.Println("Image generated:", filePath)
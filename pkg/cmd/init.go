@@ -4,32 +4,55 @@ import (
 	"bufio"
 	"fmt"
 	"os"
-
+	"strings"
 	"time"
 
 	"github.com/briandowns/spinner"
 	"github.com/fatih/color"
+	"github.com/harshalranjhani/genie/internal/config"
+	"github.com/harshalranjhani/genie/internal/secrets"
 	"github.com/spf13/cobra"
-	"github.com/zalando/go-keyring"
+)
+
+var (
+	nonInteractive bool
+	initValues     = map[string]*string{}
 )
 
 func init() {
+	initCmd.Flags().BoolVar(&nonInteractive, "non-interactive", false, "Configure genie from flags and GENIE_* environment variables without prompting")
+	for _, s := range settings {
+		initValues[s.key] = initCmd.Flags().String(s.flag, "", fmt.Sprintf("%s (or %s)", s.usage, secrets.EnvName(s.key)))
+	}
 	rootCmd.AddCommand(initCmd)
 }
 
-const serviceName = "genie"
 const openAIKeyName = "openai_api_key"
 const geminiKeyName = "gemini_api_key"
 const ignoreListPathKeyName = "ignore_list_path"
 const replicateKeyName = "replicate_api_key"
 const deepseekKeyName = "deepseek_api_key"
 const ollamaURLKeyName = "ollama_url"
+const openAICompatibleURLKeyName = "openai_compatible_url"
+const openAICompatibleKeyName = "openai_compatible_api_key"
+const openAICompatibleModelsKeyName = "openai_compatible_models"
+const fallbackChainKeyName = "fallback_chain"
+const monthlyBudgetKeyName = "monthly_budget"
+const budgetModeKeyName = "budget_mode"
+
+// stdin is shared by all prompts so that piped answers are not lost in the
+// buffer of an earlier reader.
+var stdin = bufio.NewReader(os.Stdin)
+
+// readLine reads a line from stdin without the line ending.
+func readLine() string {
+	line, _ := stdin.ReadString('\n')
+	return strings.TrimRight(line, "\r\n")
+}
 
 func getAPIKeyFromUser(promptMessage string) string {
 	fmt.Print(color.HiBlackString("Enter your key: "))
-	scanner := bufio.NewScanner(os.Stdin)
-	scanner.Scan()
-	return scanner.Text()
+	return readLine()
 }
 
 func storeKeyIfNotPresent(accountName string, promptMessage string, emoji string) string {
@@ -37,7 +60,7 @@ func storeKeyIfNotPresent(accountName string, promptMessage string, emoji string
 	s.Suffix = color.HiBlackString(" Checking existing keys...")
 	s.Start()
 
-	apiKey, err := keyring.Get(serviceName, accountName)
+	apiKey, err := secrets.Get(accountName)
 	time.Sleep(1 * time.Second)
 	s.Stop()
 
@@ -50,7 +73,7 @@ func storeKeyIfNotPresent(accountName string, promptMessage string, emoji string
 
 		s.Suffix = color.HiBlackString(" Storing key securely...")
 		s.Start()
-		err := keyring.Set(serviceName, accountName, apiKey)
+		err := secrets.Set(accountName, apiKey)
 		time.Sleep(500 * time.Millisecond)
 		s.Stop()
 
@@ -69,7 +92,7 @@ func storeKeyWithDefault(accountName string, promptMessage string, emoji string,
 	s.Suffix = color.HiBlackString(" Checking existing keys...")
 	s.Start()
 
-	apiKey, err := keyring.Get(serviceName, accountName)
+	apiKey, err := secrets.Get(accountName)
 	time.Sleep(1 * time.Second)
 	s.Stop()
 
@@ -79,9 +102,7 @@ func storeKeyWithDefault(accountName string, promptMessage string, emoji string,
 		fmt.Println(color.HiBlackString("────────────────────────────────────"))
 
 		fmt.Print(color.HiBlackString("Enter your key (press Enter for default): "))
-		scanner := bufio.NewScanner(os.Stdin)
-		scanner.Scan()
-		input := scanner.Text()
+		input := readLine()
 
 		if input == "" {
 			apiKey = defaultValue
@@ -91,7 +112,7 @@ func storeKeyWithDefault(accountName string, promptMessage string, emoji string,
 
 		s.Suffix = color.HiBlackString(" Storing key securely...")
 		s.Start()
-		err := keyring.Set(serviceName, accountName, apiKey)
+		err := secrets.Set(accountName, apiKey)
 		time.Sleep(500 * time.Millisecond)
 		s.Stop()
 
@@ -112,17 +133,26 @@ var initCmd = &cobra.Command{
 ✨ Genie Initialization Wizard
 ────────────────────────────
 This wizard will help you set up Genie by storing your API keys securely 
-in your system keychain. Each key will be encrypted and can only be 
+in your system keychain, or in an encrypted file in ~/.genie when no 
+keychain is available. Each key will be encrypted and can only be 
 accessed by Genie.
 
 Let's get started! 🚀
+
+Use --non-interactive with the flags below, or the matching GENIE_* environment
+variables, to configure genie from a script.
 `),
 	Run: func(cmd *cobra.Command, args []string) {
+		if nonInteractive {
+			initNonInteractive()
+			return
+		}
+
 		// Check for existing keys
-		_, errOpenAI := keyring.Get(serviceName, openAIKeyName)
-		_, errGemini := keyring.Get(serviceName, geminiKeyName)
-		_, errIgnoreListPath := keyring.Get(serviceName, ignoreListPathKeyName)
-		_, errDeepseek := keyring.Get(serviceName, deepseekKeyName)
+		_, errOpenAI := secrets.Get(openAIKeyName)
+		_, errGemini := secrets.Get(geminiKeyName)
+		_, errIgnoreListPath := secrets.Get(ignoreListPathKeyName)
+		_, errDeepseek := secrets.Get(deepseekKeyName)
 		if errOpenAI == nil || errGemini == nil || errIgnoreListPath == nil || errDeepseek == nil {
 			color.Yellow("\n⚠️  Some or all keys are already present!")
 			fmt.Print(color.HiBlackString("Use "))
@@ -145,11 +175,18 @@ Let's get started! 🚀
 		// Add Ollama URL with default value
 		ollamaURL := storeKeyWithDefault(ollamaURLKeyName, "Enter your Ollama URL (default: http://localhost:11434)", "🌐", "http://localhost:11434")
 
+		// OpenAI-compatible servers (vLLM, LM Studio, llama.cpp) are optional
+		compatibleURL := storeKeyWithDefault(openAICompatibleURLKeyName, "Enter the base URL of your OpenAI-compatible server, e.g. http://localhost:8000/v1 (press Enter to skip)", "🔌", "")
+		if compatibleURL != "" {
+			storeKeyWithDefault(openAICompatibleKeyName, "Enter the API key for your OpenAI-compatible server (press Enter if none)", "🔌", "")
+			storeKeyWithDefault(openAICompatibleModelsKeyName, "Enter the comma-separated models served by your OpenAI-compatible server", "🔌", "")
+		}
+
 		// Set default engine
 		s := spinner.New(spinner.CharSets[11], 100*time.Millisecond)
 		s.Suffix = color.HiBlackString(" Setting default engine...")
 		s.Start()
-		err := keyring.Set(serviceName, "engineName", "Gemini")
+		err := secrets.Set("engineName", "Gemini")
 		time.Sleep(500 * time.Millisecond)
 		s.Stop()
 
@@ -160,6 +197,9 @@ Let's get started! 🚀
 
 		// Final success message
 		fmt.Println(color.GreenString("\n🎉 Success! Genie is now configured and ready to use!\n"))
+		if caveat := secrets.Caveat(); caveat != "" {
+			color.Yellow("⚠️  %s", caveat)
+		}
 
 		// Configuration summary
 		fmt.Println(color.YellowString("📋 Configuration Summary"))
@@ -170,6 +210,9 @@ Let's get started! 🚀
 		fmt.Printf("%s Replicate API Key: %s\n", color.HiBlackString("├─ 🔄"), maskKey(replicateKey))
 		fmt.Printf("%s Ignore List Path: %s\n", color.HiBlackString("├─ 📝"), ignoreListPath)
 		fmt.Printf("%s Ollama URL: %s\n", color.HiBlackString("├─ 🌐"), ollamaURL)
+		if compatibleURL != "" {
+			fmt.Printf("%s OpenAI-compatible URL: %s\n", color.HiBlackString("├─ 🔌"), compatibleURL)
+		}
 
 		// Next steps
 		fmt.Println(color.CyanString("\n📚 Next Steps"))
@@ -180,6 +223,56 @@ Let's get started! 🚀
 	},
 }
 
+// initNonInteractive stores every setting given as a flag or GENIE_*
+// environment variable, overwriting stored values, and fills in the defaults
+// of the interactive wizard. It never prompts and exits non-zero on errors.
+func initNonInteractive() {
+	stored := 0
+	for _, s := range settings {
+		value := *initValues[s.key]
+		if value == "" {
+			value = os.Getenv(secrets.EnvName(s.key))
+		}
+		if value == "" {
+			continue
+		}
+		if err := storeSetting(s, value); err != nil {
+			color.Red("❌ %v", err)
+			os.Exit(1)
+		}
+		stored++
+	}
+
+	defaults := map[string]string{
+		accountName:      config.GeminiEngine,
+		ollamaURLKeyName: "http://localhost:11434",
+	}
+	for _, s := range settings {
+		value, ok := defaults[s.key]
+		if !ok {
+			continue
+		}
+		if _, err := secrets.Get(s.key); err == nil {
+			continue
+		}
+		if err := storeSetting(s, value); err != nil {
+			color.Red("❌ %v", err)
+			os.Exit(1)
+		}
+	}
+
+	if _, err := secrets.Get(ignoreListPathKeyName); err != nil {
+		color.Yellow("⚠️  No ignore list path set. Commands that read the directory need --ignore-list or %s.", secrets.EnvName(ignoreListPathKeyName))
+	}
+	if stored == 0 {
+		color.Yellow("⚠️  No settings were given. Pass flags such as --gemini-key or set GENIE_* environment variables.")
+	}
+	color.Green("✅ genie is configured (%s).", secrets.Backend())
+	if caveat := secrets.Caveat(); caveat != "" {
+		color.Yellow("⚠️  %s", caveat)
+	}
+}
+
 func maskKey(key string) string {
 	if len(key) <= 8 {
 		return color.HiBlackString("********")
@@ -11,9 +11,9 @@ import (
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/harshalranjhani/genie/internal/secrets"
 	"github.com/harshalranjhani/genie/internal/structs"
 	"github.com/spf13/cobra"
-	"github.com/zalando/go-keyring"
 )
 
 func init() {
@@ -39,9 +39,9 @@ var musicCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		replicateApiKey, err := keyring.Get("genie", "replicate_api_key")
+		replicateApiKey, err := secrets.Get("replicate_api_key")
 		if err != nil {
-			fmt.Println("Replicate API key not found in keyring. Please run `genie init` to store the key.")
+			fmt.Println("Replicate API key not found. Please run `genie init` to store the key.")
 			os.Exit(1)
 		}
 
@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/harshalranjhani/genie/internal/config"
+	"github.com/harshalranjhani/genie/internal/helpers/llm"
+	"github.com/harshalranjhani/genie/internal/secrets"
+	"github.com/spf13/cobra"
+)
+
+// profileKeyNames are the API keys a profile can carry its own value for.
+var profileKeyNames = []string{openAIKeyName, geminiKeyName, deepseekKeyName, replicateKeyName, openAICompatibleKeyName}
+
+var (
+	profileEngine      string
+	profileModel       string
+	profileKeys        []string
+	profileIgnoreList  string
+	profileOllamaURL   string
+	profileSafe        bool
+	profileTemperature float32
+	profileNone        bool
+)
+
+func init() {
+	profileCreateCmd.Flags().StringVar(&profileEngine, "set-engine", "", "Engine of the profile (GPT, Gemini, DeepSeek, Ollama, OpenAICompatible)")
+	profileCreateCmd.Flags().StringVar(&profileModel, "set-model", "", "Model of the profile (default the engine's default model)")
+	profileCreateCmd.Flags().StringSliceVar(&profileKeys, "key", nil, "API key the profile keeps its own value for, e.g. openai_api_key (asked for interactively)")
+	profileCreateCmd.Flags().StringVar(&profileIgnoreList, "ignore-list", "", "Path to the ignore list of the profile")
+	profileCreateCmd.Flags().StringVar(&profileOllamaURL, "ollama-url", "", "Ollama URL of the profile")
+	profileCreateCmd.Flags().BoolVar(&profileSafe, "safe", false, "Turn safe mode on by default")
+	profileCreateCmd.Flags().Float32Var(&profileTemperature, "temperature", 0, "Default temperature of requests (default the engine's)")
+	profileUseCmd.Flags().BoolVar(&profileNone, "none", false, "Stop using profiles and go back to the global settings")
+
+	profileCmd.AddCommand(profileCreateCmd, profileListCmd, profileUseCmd, profileDeleteCmd, profileShowCmd)
+	rootCmd.AddCommand(profileCmd)
+}
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage named configuration profiles",
+	Long: `A profile bundles an engine, a model, its own API keys, an ignore list, an Ollama URL and generation defaults.
+While a profile is active, every command uses its settings, and genie use, genie switch and genie reset change the profile.
+Settings the profile leaves empty come from the global configuration.
+Example: genie profile create work --set-engine GPT --key openai_api_key --safe`,
+}
+
+var profileCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a profile",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		if err := config.ValidateProfileName(name); err != nil {
+			color.Red("%v", err)
+			return
+		}
+		if _, exists, err := config.GetProfile(name); err != nil {
+			color.Red("Failed to read profiles: %v", err)
+			return
+		} else if exists {
+			color.Red("Profile %s already exists. Delete it first or pick another name.", name)
+			return
+		}
+
+		profile := config.Profile{
+			Name:           name,
+			Model:          profileModel,
+			IgnoreListPath: profileIgnoreList,
+			OllamaURL:      profileOllamaURL,
+			SafeMode:       profileSafe,
+			Temperature:    profileTemperature,
+		}
+
+		if profileEngine != "" {
+			engine, exists := config.CheckAndGetEngine(profileEngine)
+			if !exists {
+				color.Red("Invalid engine. Available engines: GPT, Gemini, DeepSeek, Ollama, OpenAICompatible")
+				return
+			}
+			profile.Engine = engine.Name
+		}
+
+		for _, key := range profileKeys {
+			if !slices.Contains(profileKeyNames, key) {
+				color.Red("Unknown key %s. Profiles can keep: %s", key, strings.Join(profileKeyNames, ", "))
+				return
+			}
+		}
+		for _, key := range profileKeys {
+			fmt.Printf("🔑 %s\n", color.CyanString("Enter the %s for profile %s", key, name))
+			value := getAPIKeyFromUser(key)
+			entry := config.ProfileKeyEntry(name, key)
+			if err := secrets.Set(entry, value); err != nil {
+				color.Red("❌ Failed to store %s: %s", key, err)
+				return
+			}
+			if profile.Keys == nil {
+				profile.Keys = map[string]string{}
+			}
+			profile.Keys[key] = entry
+		}
+
+		if err := config.SaveProfile(profile); err != nil {
+			color.Red("Failed to save the profile: %v", err)
+			return
+		}
+		color.Green("✓ Profile %s created. Activate it with: genie profile use %s", name, name)
+	},
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles",
+	Run: func(cmd *cobra.Command, args []string) {
+		profiles, err := config.ListProfiles()
+		if err != nil {
+			color.Red("Failed to read profiles: %v", err)
+			return
+		}
+		active, _, _ := config.ActiveProfile()
+
+		fmt.Println(color.HiMagentaString("👤 Profiles"))
+		fmt.Println(strings.Repeat("─", 50))
+		if len(profiles) == 0 {
+			color.Yellow("  No profiles yet. Create one with: genie profile create <name>")
+		}
+		for _, profile := range profiles {
+			engine := profile.Engine
+			if engine == "" {
+				engine = "global engine"
+			}
+			if profile.Name == active.Name {
+				color.Green("  • %s (active) - %s", profile.Name, engine)
+			} else {
+				fmt.Printf("  • %s - %s\n", profile.Name, engine)
+			}
+		}
+		fmt.Println(strings.Repeat("─", 50))
+	},
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Activate a profile for all later commands",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if profileNone {
+			if err := config.SetActiveProfile(""); err != nil {
+				color.Red("Failed to deactivate the profile: %v", err)
+				return
+			}
+			color.Green("✓ No profile active. Using the global settings.")
+			return
+		}
+		if len(args) == 0 {
+			color.Red("Please provide a profile name, or --none to use the global settings")
+			return
+		}
+
+		if err := config.SetActiveProfile(args[0]); err != nil {
+			color.Red("%v", err)
+			return
+		}
+		color.Green("✓ Profile %s is now active.", args[0])
+	},
+}
+
+var profileDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a profile and the API keys stored for it",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		profile, exists, err := config.GetProfile(args[0])
+		if err != nil {
+			color.Red("Failed to read profiles: %v", err)
+			return
+		}
+		if !exists {
+			color.Red("Profile %s does not exist.", args[0])
+			return
+		}
+
+		if err := config.DeleteProfile(profile.Name); err != nil {
+			color.Red("Failed to delete the profile: %v", err)
+			return
+		}
+		for _, entry := range profile.Keys {
+			if err := secrets.Delete(entry); err != nil && !errors.Is(err, secrets.ErrNotFound) {
+				color.Yellow("Could not delete %s: %v", entry, err)
+			}
+		}
+		color.Green("✓ Profile %s deleted.", profile.Name)
+	},
+}
+
+var profileShowCmd = &cobra.Command{
+	Use:   "show [name]",
+	Short: "Show a profile (default the active one)",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var profile config.Profile
+		var exists bool
+		var err error
+		if len(args) == 1 {
+			profile, exists, err = config.GetProfile(args[0])
+		} else {
+			profile, exists, err = config.ActiveProfile()
+		}
+		if err != nil {
+			color.Red("%v", err)
+			return
+		}
+		if !exists && len(args) == 1 {
+			color.Red("Profile %s does not exist.", args[0])
+			return
+		}
+		if !exists {
+			color.Yellow("No profile active. Pass a name or run: genie profile use <name>")
+			return
+		}
+
+		fmt.Println(color.HiMagentaString("👤 Profile %s", profile.Name))
+		fmt.Println(strings.Repeat("─", 50))
+		printProfileSetting("Engine", profile.Engine)
+		printProfileSetting("Model", profile.Model)
+		printProfileSetting("Ignore List", profile.IgnoreListPath)
+		printProfileSetting("Ollama URL", profile.OllamaURL)
+		color.Cyan("Safe Mode:")
+		fmt.Printf("  • %t\n", profile.SafeMode)
+		if profile.Temperature != 0 {
+			printProfileSetting("Temperature", fmt.Sprintf("%g", profile.Temperature))
+		}
+		color.Cyan("API Keys:")
+		if len(profile.Keys) == 0 {
+			fmt.Println("  • global keys")
+		}
+		for _, key := range profileKeyNames {
+			if entry, ok := profile.Keys[key]; ok {
+				value, err := secrets.Get(entry)
+				if err != nil {
+					color.Yellow("  • %s: missing (%s)", key, entry)
+				} else {
+					fmt.Printf("  • %s: %s\n", key, maskKey(value))
+				}
+			}
+		}
+		fmt.Println(strings.Repeat("─", 50))
+	},
+}
+
+func printProfileSetting(name, value string) {
+	color.Cyan("%s:", name)
+	if value == "" {
+		value = "global setting"
+	}
+	fmt.Printf("  • %s\n", value)
+}
+
+// applyProfileDefaults hands the generation defaults of the active profile to
+// the providers.
+func applyProfileDefaults() {
+	profile, active, err := config.ActiveProfile()
+	if err != nil || !active {
+		return
+	}
+	llm.SetDefaultOptions(llm.Options{Temperature: profile.Temperature})
+}
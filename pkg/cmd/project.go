@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/harshalranjhani/genie/internal/config"
+	"github.com/harshalranjhani/genie/internal/helpers/llm"
+	"github.com/spf13/cobra"
+)
+
+// commandName is the top-level command being run, e.g. "bug" for
+// genie bug report. It keys the prompt additions of .genie.yaml.
+var commandName string
+
+// setupCommands are the commands that keep working with a broken
+// .genie.yaml, so that genie can still be set up from inside the project.
+var setupCommands = map[string]bool{"init": true, "reset": true, "switch": true}
+
+// runningCommands are the commands whose prompts produce commands that are
+// run on the user's machine.
+var runningCommands = map[string]bool{"do": true, "run": true}
+
+// loadProject reads the project's .genie.yaml before any command runs. A
+// broken file stops genie rather than being silently ignored, except for the
+// setup commands, which ignore it with a warning.
+func loadProject(cmd *cobra.Command) {
+	for cmd.HasParent() && cmd.Parent() != rootCmd {
+		cmd = cmd.Parent()
+	}
+	commandName = cmd.Name()
+
+	if _, err := config.LoadProject(); err != nil {
+		if setupCommands[commandName] {
+			color.Yellow("Ignoring the project configuration: %v", err)
+			return
+		}
+		color.Red("%v", err)
+		os.Exit(1)
+	}
+}
+
+// currentProject returns the project configuration, or nil outside a project.
+func currentProject() *config.Project {
+	project, _ := config.LoadProject()
+	return project
+}
+
+// applyProjectDefaults hands the prompt additions of the project to the
+// providers. A .genie.yaml comes with whatever repository was cloned, so the
+// additions of commands that run what the engine proposes are shown first and
+// only used once the user agrees.
+func applyProjectDefaults() {
+	project := currentProject()
+	if project == nil {
+		return
+	}
+	addition := project.PromptAddition(commandName)
+	if addition == "" {
+		return
+	}
+	if runningCommands[commandName] && !confirmPromptAddition(project.Path, addition) {
+		return
+	}
+	llm.SetPromptAddition(addition)
+}
+
+// confirmPromptAddition shows the prompt additions of a project and asks
+// whether to use them. Without a terminal to ask on, they are left out.
+func confirmPromptAddition(path, addition string) bool {
+	color.Yellow("📄 %s adds these instructions to the prompt:", path)
+	fmt.Print(indent(addition))
+	if !stdinIsTerminal() {
+		color.Yellow("Leaving them out: stdin is not a terminal to confirm them on.")
+		return false
+	}
+	fmt.Print(color.HiCyanString("Use them? [y/N]: "))
+	if answer := strings.ToLower(strings.TrimSpace(readLine())); answer == "y" || answer == "yes" {
+		return true
+	}
+	color.Yellow("Leaving them out.")
+	return false
+}
+
+// warnProjectSelection tells the user that the engine or model they just
+// stored is shadowed by the project's .genie.yaml in this directory.
+func warnProjectSelection() {
+	if project := currentProject(); project != nil && (project.Engine != "" || project.Model != "") {
+		color.Yellow("Note: %s sets the engine or model for this project and takes precedence here.", project.Path)
+	}
+}
+
+// safeModeDefault returns the safe mode used when --safe is not given and
+// where it comes from: the active profile or the project. A project can turn
+// safe mode on, but not off, since its .genie.yaml may come from anyone.
+func safeModeDefault() (bool, string) {
+	if profile, active, err := config.ActiveProfile(); err == nil && active && profile.SafeMode {
+		return true, "profile " + profile.Name
+	}
+	if project := currentProject(); project != nil && project.SafeMode != nil && *project.SafeMode {
+		return true, "project (" + project.Path + ")"
+	}
+	return false, "default"
+}
+
+// safeModeFlag returns the --safe flag of cmd, or safeModeDefault when the
+// flag is not given.
+func safeModeFlag(cmd *cobra.Command) bool {
+	if cmd.Flags().Changed("safe") {
+		safe, _ := cmd.Flags().GetBool("safe")
+		return safe
+	}
+	safe, _ := safeModeDefault()
+	return safe
+}
+
+// readmeTemplate returns the --template flag, or the project's template when
+// the flag is not given.
+func readmeTemplate(cmd *cobra.Command) (string, string) {
+	if project := currentProject(); !cmd.Flags().Changed("template") && project != nil && project.ReadmeTemplate != "" {
+		return project.ReadmeTemplate, "project (" + project.Path + ")"
+	}
+	return templateName, "default"
+}
+
+// bugOutputDir returns where bug reports are written and where that comes
+// from: the project's bug_output_dir, or ./bugs.
+func bugOutputDir() (string, string) {
+	if project := currentProject(); project != nil && project.BugOutputDir != "" {
+		return project.BugOutputDir, "project (" + project.Path + ")"
+	}
+	return filepath.Join(".", "bugs"), "default"
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/harshalranjhani/genie/internal/helpers/llm"
+	"github.com/harshalranjhani/genie/internal/helpers/shell"
+)
+
+// doProposal is the JSON object genie do asks the engine for.
+type doProposal struct {
+	Command      string   `json:"command"`
+	Explanation  string   `json:"explanation"`
+	Risk         string   `json:"risk"`
+	RequiresSudo bool     `json:"requires_sudo"`
+	Alternatives []string `json:"alternatives"`
+}
+
+var doSchema = &llm.Schema{
+	Type: "object",
+	Properties: map[string]*llm.Schema{
+		"command":       {Type: "string", Description: "The command to run in the user's shell"},
+		"explanation":   {Type: "string", Description: "One short sentence explaining what the command does"},
+		"risk":          {Type: "string", Enum: []string{"low", "medium", "high"}},
+		"requires_sudo": {Type: "boolean"},
+		"alternatives":  {Type: "array", Items: &llm.Schema{Type: "string"}},
+	},
+	Required: []string{"command", "explanation", "risk", "requires_sudo", "alternatives"},
+}
+
+// agentProposal is the JSON object genie do --agent asks the engine for at
+// each step: the next command, or done with a summary for the user.
+type agentProposal struct {
+	Done    bool   `json:"done"`
+	Summary string `json:"summary"`
+	doProposal
+}
+
+var agentSchema = &llm.Schema{
+	Type: "object",
+	Properties: map[string]*llm.Schema{
+		"done":          {Type: "boolean", Description: "Whether the task is finished, or cannot be finished"},
+		"summary":       {Type: "string", Description: "When done, a short answer for the user"},
+		"command":       doSchema.Properties["command"],
+		"explanation":   doSchema.Properties["explanation"],
+		"risk":          doSchema.Properties["risk"],
+		"requires_sudo": doSchema.Properties["requires_sudo"],
+		"alternatives":  doSchema.Properties["alternatives"],
+	},
+	Required: append([]string{"done", "summary"}, doSchema.Required...),
+}
+
+// validate normalizes the proposal and rejects one that cannot be shown or
+// run. The error is sent back to the engine, so it says what to fix.
+func (p *doProposal) validate() error {
+	p.Command = shell.CleanCommand(p.Command)
+	if p.Command == "" {
+		return errors.New(`"command" is empty`)
+	}
+	p.Explanation = strings.TrimSpace(p.Explanation)
+	p.Risk = strings.ToLower(strings.TrimSpace(p.Risk))
+	if _, ok := shell.ParseRisk(p.Risk); !ok {
+		return fmt.Errorf(`"risk" is %q, it must be "low", "medium" or "high"`, p.Risk)
+	}
+
+	var alternatives []string
+	for _, alternative := range p.Alternatives {
+		if alternative = shell.CleanCommand(alternative); alternative != "" && alternative != p.Command {
+			alternatives = append(alternatives, alternative)
+		}
+	}
+	p.Alternatives = alternatives
+	return nil
+}
+
+// validate accepts a finished task as it is and checks the command of any
+// other step like a do proposal.
+func (p *agentProposal) validate() error {
+	p.Summary = strings.TrimSpace(p.Summary)
+	if p.Done {
+		return nil
+	}
+	return p.doProposal.validate()
+}
+
+// printProposalNotes shows what the engine said about its command beyond the
+// explanation: a higher risk than genie's own assessment, root privileges and
+// alternatives.
+func printProposalNotes(p doProposal, shellName string) {
+	risk, _ := shell.ParseRisk(p.Risk)
+	if risk > shell.Assess(p.Command, shellName).Risk {
+		color.Yellow("⚠️  The genie rates this command %s risk.", risk)
+	}
+	if p.RequiresSudo {
+		color.Yellow("🔑 The command needs root privileges.")
+	}
+	if len(p.Alternatives) > 0 {
+		color.Cyan("🔀 Alternatives:")
+		for _, alternative := range p.Alternatives {
+			fmt.Printf("  • %s\n", alternative)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/fatih/color"
+	"github.com/harshalranjhani/genie/internal/config"
+	"github.com/harshalranjhani/genie/internal/helpers"
+	"github.com/harshalranjhani/genie/internal/helpers/llm"
+	"github.com/harshalranjhani/genie/internal/helpers/markdown"
+	"github.com/harshalranjhani/genie/internal/helpers/usage"
+	"github.com/harshalranjhani/genie/internal/secrets"
+	"github.com/harshalranjhani/genie/internal/structs"
+)
+
+// Environment variables that override the stored engine and model, below the
+// --engine and --model flags. They are the environment layer of the secret
+// store, so init reads them too.
+var (
+	engineEnvVar = secrets.EnvName(accountName)
+	modelEnvVar  = secrets.EnvName(modelAccountKey)
+)
+
+// overrideLayer ranks the --engine and --model flags above every layer of the
+// secret store.
+const overrideLayer = secrets.LayerEnv - 1
+
+// currentEngine returns the engine for this invocation and where it came
+// from: the --engine flag, then GENIE_ENGINE, then the project, profile or
+// stored engine.
+func currentEngine() (secrets.Value, error) {
+	if engineOverride != "" {
+		engine, exists := config.CheckAndGetEngine(engineOverride)
+		if !exists {
+			return secrets.Value{}, fmt.Errorf("unknown engine name: %s", engineOverride)
+		}
+		return secrets.Value{Value: engine.Name, Source: "--engine flag", Layer: overrideLayer}, nil
+	}
+
+	engine, err := secrets.Lookup(accountName)
+	if err != nil {
+		return secrets.Value{}, fmt.Errorf("error retrieving engine name: %w", err)
+	}
+	if engine.Layer == secrets.LayerEnv {
+		known, exists := config.CheckAndGetEngine(engine.Value)
+		if !exists {
+			return secrets.Value{}, fmt.Errorf("unknown engine name in %s: %s", engineEnvVar, engine.Value)
+		}
+		engine.Value = known.Name
+	}
+	return engine, nil
+}
+
+// currentEngineName returns the name of currentEngine.
+func currentEngineName() (string, error) {
+	engine, err := currentEngine()
+	return engine.Value, err
+}
+
+// currentModel returns the model for this invocation and where it came from:
+// the --model flag, then GENIE_MODEL, then the project, profile or stored
+// model. A model is only used together with the engine it was chosen for, so
+// a model from a layer below the engine's is dropped when that layer selects
+// another engine; an empty value means the engine's default model. The flag
+// and GENIE_MODEL are chosen for this invocation and always kept.
+func currentModel(engineName string) secrets.Value {
+	if modelOverride != "" {
+		return secrets.Value{Value: modelOverride, Source: "--model flag", Layer: overrideLayer}
+	}
+
+	engineDefault := secrets.Value{Source: "engine default", Layer: secrets.LayerStore + 1}
+	model, err := secrets.Lookup(modelAccountKey)
+	if err != nil || model.Value == "" {
+		return engineDefault
+	}
+	if model.Layer == secrets.LayerEnv {
+		return model
+	}
+	chosenFor, err := secrets.LookupFrom(accountName, model.Layer)
+	if err != nil || !strings.EqualFold(chosenFor.Value, engineName) {
+		return engineDefault
+	}
+	return model
+}
+
+// currentModelName returns the name of currentModel.
+func currentModelName(engineName string) string {
+	return currentModel(engineName).Value
+}
+
+// engineOverrideName returns the engine requested by --engine or GENIE_ENGINE.
+func engineOverrideName() (string, bool) {
+	if engineOverride != "" {
+		return engineOverride, true
+	}
+	if name := os.Getenv(engineEnvVar); name != "" {
+		return name, true
+	}
+	return "", false
+}
+
+// selectionOverridden reports whether the engine or model of this invocation
+// differs from the stored selection because of a flag or environment variable.
+func selectionOverridden() bool {
+	_, overridden := engineOverrideName()
+	return overridden || modelOverride != "" || os.Getenv(modelEnvVar) != ""
+}
+
+// getProvider returns the provider for the engine and model of this
+// invocation, see currentEngineName and currentModelName.
+func getProvider() (llm.Provider, structs.Engine, error) {
+	engineName, err := currentEngineName()
+	if err != nil {
+		return nil, structs.Engine{}, err
+	}
+
+	engine, exists := config.CheckAndGetEngine(engineName)
+	if !exists {
+		return nil, structs.Engine{}, fmt.Errorf("unknown engine name: %s", engineName)
+	}
+
+	checkBudget()
+	applyProfileDefaults()
+	applyProjectDefaults()
+
+	// An unset model falls back to the engine's default
+	model := currentModelName(engine.Name)
+
+	chain := getFallbackChain(engine.Name)
+	if len(chain) > 1 {
+		return llm.NewWithFallback(engine.Name, model, chain[1:]), engine, nil
+	}
+
+	provider, err := llm.New(engine.Name, model)
+	if err != nil {
+		return nil, engine, err
+	}
+	return provider, engine, nil
+}
+
+// reportFallback tells the user when the request was answered by another
+// engine than the configured one.
+func reportFallback(provider llm.Provider) {
+	if fallback, ok := provider.(*llm.FallbackProvider); ok {
+		if notice := fallback.Notice(); notice != "" {
+			color.Yellow("↪️  %s", notice)
+		}
+	}
+}
+
+// contextBudget returns how many tokens of directory and git context fit into
+// a request to provider next to basePrompt.
+func contextBudget(provider llm.Provider, basePrompt string) int {
+	return helpers.ContextBudget(provider.Name(), provider.Model(), usage.EstimateTokens(basePrompt))
+}
+
+// reportTrimmed tells the user which context was left out of the prompt.
+func reportTrimmed(notes []string) {
+	for _, note := range notes {
+		color.Yellow("✂️  %s", note)
+	}
+}
+
+// requestContext returns the context for an engine request. It is cancelled
+// by Ctrl-C and by the --timeout deadline.
+func requestContext() (context.Context, context.CancelFunc) {
+	return llm.RequestContext(requestTimeout)
+}
+
+// exitIfCancelled ends the command quietly when err comes from a request
+// that was interrupted or ran past --timeout.
+func exitIfCancelled(err error) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		color.Yellow("\nRequest cancelled.")
+		os.Exit(130)
+	case errors.Is(err, context.DeadlineExceeded):
+		color.Red("\nRequest timed out after %s.", requestTimeout)
+		os.Exit(1)
+	}
+}
+
+// newSpinner returns a started spinner with the given prefix.
+func newSpinner(prefix string) *spinner.Spinner {
+	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+	s.Prefix = color.HiCyanString(prefix)
+	s.Start()
+	return s
+}
+
+// printStream renders a streamed Markdown response to stdout and returns the
+// full unformatted text.
+func printStream(stream llm.Stream) (string, error) {
+	defer stream.Close()
+
+	renderer := markdown.NewRenderer(os.Stdout)
+	var full strings.Builder
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return full.String(), renderer.Flush()
+		}
+		if err != nil {
+			renderer.Flush()
+			return full.String(), err
+		}
+
+		full.WriteString(chunk.Content)
+		if err := renderer.Write(chunk.Content); err != nil {
+			return full.String(), err
+		}
+	}
+}
+
+// availableModels discovers the models of an engine, warning when the
+// provider could not be reached and a cached or static list is shown instead.
+func availableModels(engineName string, refresh bool) []string {
+	s := newSpinner("Fetching models ")
+	list := llm.DiscoverModels(engineName, refresh)
+	s.Stop()
+
+	if list.Err != nil {
+		color.Yellow("Could not fetch models for %s: %v", engineName, list.Err)
+		color.Yellow("Showing the %s model list instead.", list.Source)
+	}
+	return list.Models
+}
+
+// matchModel looks up name case-insensitively and returns the model name
+// with the casing the provider uses.
+func matchModel(models []string, name string) (string, bool) {
+	for _, model := range models {
+		if strings.EqualFold(model, name) {
+			return model, true
+		}
+	}
+	return "", false
+}
+
+func printModelList(models []string, currentModel string) {
+	if len(models) == 0 {
+		color.Yellow("  No models found.")
+		return
+	}
+	for _, model := range models {
+		if model == currentModel {
+			color.Green("  • %s (current)", model)
+		} else {
+			fmt.Printf("  • %s\n", model)
+		}
+	}
+}
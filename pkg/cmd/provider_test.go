@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/harshalranjhani/genie/internal/config"
+	"github.com/harshalranjhani/genie/internal/secrets"
+)
+
+func TestCurrentSelectionPrecedence(t *testing.T) {
+	tests := []struct {
+		name                  string
+		flagEngine, flagModel string
+		envEngine, envModel   string
+		project               string
+		profile               bool
+		wantEngine            string
+		wantModel             string
+		wantLayer             int
+	}{
+		{name: "store", wantEngine: "Gemini", wantModel: "gemini-store", wantLayer: secrets.LayerStore},
+		{name: "profile", profile: true, wantEngine: "GPT", wantModel: "gpt-profile", wantLayer: secrets.LayerProfile},
+		{name: "project", project: "engine: deepseek\nmodel: deepseek-project\n", profile: true, wantEngine: "DeepSeek", wantModel: "deepseek-project", wantLayer: secrets.LayerProject},
+		{name: "env", envEngine: "ollama", envModel: "llama-env", project: "engine: DeepSeek\n", profile: true, wantEngine: "Ollama", wantModel: "llama-env", wantLayer: secrets.LayerEnv},
+		{name: "flag", flagEngine: "GPT", flagModel: "gpt-flag", envEngine: "Ollama", envModel: "llama-env", profile: true, wantEngine: "GPT", wantModel: "gpt-flag", wantLayer: overrideLayer},
+		// A model is dropped when a higher layer picks another engine
+		{name: "env engine only", envEngine: "Ollama", wantEngine: "Ollama", wantModel: "", wantLayer: secrets.LayerEnv},
+		{name: "flag engine only", flagEngine: "GPT", profile: true, wantEngine: "GPT", wantModel: "gpt-profile", wantLayer: overrideLayer},
+		{name: "project engine only", project: "engine: Ollama\n", profile: true, wantEngine: "Ollama", wantModel: "", wantLayer: secrets.LayerProject},
+		// GENIE_MODEL is chosen for this invocation and kept with any engine
+		{name: "env model only", envModel: "gemini-env", wantEngine: "Gemini", wantModel: "gemini-env", wantLayer: secrets.LayerStore},
+		{name: "env model with flag engine", flagEngine: "Ollama", envModel: "llama-env", wantEngine: "Ollama", wantModel: "llama-env", wantLayer: overrideLayer},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("GENIE_PROFILE", "")
+			t.Setenv(engineEnvVar, test.envEngine)
+			t.Setenv(modelEnvVar, test.envModel)
+			useMemoryStore(map[string]string{accountName: "Gemini", modelAccountKey: "gemini-store"})
+
+			dir := t.TempDir()
+			if test.project != "" {
+				if err := os.WriteFile(filepath.Join(dir, config.ProjectFileName), []byte(test.project), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Chdir(dir)
+			config.ResetProject()
+			t.Cleanup(config.ResetProject)
+
+			if test.profile {
+				if err := config.SaveProfile(config.Profile{Name: "work", Engine: "GPT", Model: "gpt-profile"}); err != nil {
+					t.Fatal(err)
+				}
+				config.SetProfileOverride("work")
+				t.Cleanup(func() { config.SetProfileOverride("") })
+			}
+			engineOverride, modelOverride = test.flagEngine, test.flagModel
+			t.Cleanup(func() { engineOverride, modelOverride = "", "" })
+
+			engine, err := currentEngine()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if engine.Value != test.wantEngine || engine.Layer != test.wantLayer {
+				t.Errorf("engine is %s from %s, want %s from layer %d", engine.Value, engine.Source, test.wantEngine, test.wantLayer)
+			}
+			if model := currentModel(engine.Value); model.Value != test.wantModel {
+				t.Errorf("model is %q from %s, want %q", model.Value, model.Source, test.wantModel)
+			}
+		})
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	tests := map[string]string{
+		openAIKeyName:         "GENIE_OPENAI_API_KEY",
+		ignoreListPathKeyName: "GENIE_IGNORE_LIST_PATH",
+		accountName:           "GENIE_ENGINE",
+		modelAccountKey:       "GENIE_MODEL",
+	}
+	for key, want := range tests {
+		if got := secrets.EnvName(key); got != want {
+			t.Errorf("EnvName(%s) = %s, want %s", key, got, want)
+		}
+	}
+}
@@ -1,17 +1,20 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/briandowns/spinner"
 	"github.com/fatih/color"
-	"github.com/harshalranjhani/genie/internal/config"
+	"github.com/harshalranjhani/genie/internal/helpers"
 	"github.com/harshalranjhani/genie/internal/helpers/llm"
 	"github.com/harshalranjhani/genie/internal/middleware"
+	"github.com/harshalranjhani/genie/pkg/prompts"
 	"github.com/spf13/cobra"
-	"github.com/zalando/go-keyring"
 )
 
 var templateName string
@@ -28,6 +31,7 @@ var readmeCmd = &cobra.Command{
 	Short: "Generate README.md for the current directory",
 	Long:  `This command generates a README file for your project. You can select from a list of templates or use the default template, and specify the output filename.`,
 	PreRun: func(cmd *cobra.Command, args []string) {
+		templateName, _ = readmeTemplate(cmd)
 		if templateName == "animated" || templateName == "interactive" {
 			if err := middleware.VerifySubscriptionMiddleware(cmd, args); err != nil {
 				log.Fatal(err)
@@ -35,14 +39,9 @@ var readmeCmd = &cobra.Command{
 		}
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		engineName, err := keyring.Get(serviceName, "engineName")
+		provider, _, err := getProvider()
 		if err != nil {
-			log.Fatal("Error retrieving engine name from keyring:", err)
-		}
-
-		_, exists := config.CheckAndGetEngine(engineName)
-		if !exists {
-			log.Fatal("Unknown engine name: ", engineName)
+			log.Fatal(err)
 		}
 
 		cwd, err := os.Getwd()
@@ -52,33 +51,51 @@ var readmeCmd = &cobra.Command{
 		}
 
 		readmePath := filepath.Join(cwd, readmeFileName)
-		switch engineName {
-		case config.GPTEngine:
-			err := llm.GenerateReadmeWithGPT(readmePath, templateName)
-			if err != nil {
-				log.Fatalf("Failed to generate README with GPT: %v", err)
-			}
-		case config.GeminiEngine:
-			err := llm.GenerateReadmeWithGemini(readmePath, templateName)
-			if err != nil {
-				log.Fatalf("Failed to generate README with Gemini: %v", err)
-			}
-		case config.DeepSeekEngine:
-			err := llm.GenerateReadmeWithDeepSeek(readmePath, templateName)
-			if err != nil {
-				log.Fatalf("Failed to generate README with DeepSeek: %v", err)
-			}
-		case config.OllamaEngine:
-			model, err := keyring.Get(serviceName, modelAccountKey)
-			if err != nil {
-				log.Fatal("Error retrieving model name from keyring: ", err)
-			}
-			err = llm.GenerateReadmeWithOllama(readmePath, templateName, model)
-			if err != nil {
-				log.Fatalf("Failed to generate README with Ollama: %v", err)
-			}
+		ctx, cancel := requestContext()
+		defer cancel()
+
+		if err := generateReadme(ctx, provider, cwd, readmePath, templateName); err != nil {
+			exitIfCancelled(err)
+			log.Fatalf("Failed to generate README with %s: %v", provider.Name(), err)
 		}
+		reportFallback(provider)
 
 		fmt.Printf("%s generated successfully!\n", readmeFileName)
 	},
 }
+
+func generateReadme(ctx context.Context, provider llm.Provider, cwd string, readmePath string, templateName string) error {
+	rootDir, err := helpers.GetCurrentDirectoriesAndFiles(cwd)
+	if err != nil {
+		return fmt.Errorf("failed to get directory structure: %w", err)
+	}
+
+	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+	s.Prefix = color.HiCyanString("Generating README: ")
+	s.Start()
+	defer s.Stop()
+
+	// get project name from root folder name
+	projectName := filepath.Base(cwd)
+
+	budget := contextBudget(provider, prompts.GetReadmePrompt("", templateName, projectName))
+	repoData, notes := helpers.RenderDirectory(rootDir, budget)
+	reportTrimmed(notes)
+
+	sanitizedRepoData := helpers.SanitizeUTF8(repoData)
+
+	prompt := prompts.GetReadmePrompt(sanitizedRepoData, templateName, projectName)
+
+	generatedText, err := provider.Complete(ctx, prompt, llm.Options{
+		System: "You are a helpful assistant who generates README files.",
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := helpers.ProcessTemplateResponse(templateName, generatedText, readmePath); err != nil {
+		return fmt.Errorf("failed to process template response: %w", err)
+	}
+
+	return nil
+}
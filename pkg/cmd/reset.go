@@ -9,19 +9,22 @@ import (
 
 	"github.com/briandowns/spinner"
 	"github.com/fatih/color"
+	"github.com/harshalranjhani/genie/internal/secrets"
 	"github.com/spf13/cobra"
-	"github.com/zalando/go-keyring"
 )
 
 var keys = map[int]string{
-	1: openAIKeyName,
-	2: geminiKeyName,
-	3: deepseekKeyName,
-	4: replicateKeyName,
-	5: ignoreListPathKeyName,
-	6: ollamaURLKeyName,
-	7: "all",
-	8: "purge",
+	1:  openAIKeyName,
+	2:  geminiKeyName,
+	3:  deepseekKeyName,
+	4:  replicateKeyName,
+	5:  ignoreListPathKeyName,
+	6:  ollamaURLKeyName,
+	7:  openAICompatibleURLKeyName,
+	8:  openAICompatibleKeyName,
+	9:  openAICompatibleModelsKeyName,
+	10: "all",
+	11: "purge",
 }
 
 func resetKey(keyName string) {
@@ -30,7 +33,7 @@ func resetKey(keyName string) {
 	s.Start()
 	time.Sleep(500 * time.Millisecond)
 
-	err := keyring.Delete(serviceName, keyName)
+	err := secrets.Delete(keyName)
 	s.Stop()
 
 	if err != nil {
@@ -47,9 +50,9 @@ func resetKeys() {
 	time.Sleep(500 * time.Millisecond)
 	s.Stop()
 
-	keys := []string{openAIKeyName, geminiKeyName, deepseekKeyName, ignoreListPathKeyName, replicateKeyName, ollamaURLKeyName}
+	keys := []string{openAIKeyName, geminiKeyName, deepseekKeyName, ignoreListPathKeyName, replicateKeyName, ollamaURLKeyName, openAICompatibleURLKeyName, openAICompatibleKeyName, openAICompatibleModelsKeyName, fallbackChainKeyName, monthlyBudgetKeyName, budgetModeKeyName}
 	for _, key := range keys {
-		if err := keyring.Delete(serviceName, key); err != nil {
+		if err := secrets.Delete(key); err != nil {
 			color.Red("✘ Failed to delete %s: %s\n", key, err)
 		} else {
 			color.Green("✔ Successfully deleted %s\n", key)
@@ -65,14 +68,14 @@ func purgeGenieService() {
 	s.Stop()
 
 	// Delete all known keys first
-	keys := []string{openAIKeyName, geminiKeyName, deepseekKeyName, ignoreListPathKeyName, replicateKeyName, ollamaURLKeyName}
+	keys := []string{openAIKeyName, geminiKeyName, deepseekKeyName, ignoreListPathKeyName, replicateKeyName, ollamaURLKeyName, openAICompatibleURLKeyName, openAICompatibleKeyName, openAICompatibleModelsKeyName, fallbackChainKeyName, monthlyBudgetKeyName, budgetModeKeyName}
 	for _, key := range keys {
-		_ = keyring.Delete(serviceName, key)
+		_ = secrets.Delete(key)
 	}
 
-	// Delete the service itself if supported by the system keyring
-	if err := keyring.Delete(serviceName, serviceName); err != nil {
-		color.Yellow("Note: Service entry could not be removed (this is normal on some systems)")
+	// Delete the store itself if the backend supports it
+	if err := secrets.Purge(); err != nil {
+		color.Yellow("Note: Secret store could not be removed: %v", err)
 	}
 
 	color.Red("🗑️  All Genie data has been purged from your system")
@@ -88,7 +91,7 @@ var resetCmd = &cobra.Command{
 	Long: color.YellowString(`
 🔄 Genie Reset Wizard
 --------------------
-This wizard will help you reset and update your API keys stored in the system keychain.
+This wizard will help you reset and update your API keys stored in the system keychain or the encrypted secrets file.
 You can choose to reset individual keys or all of them at once.
 `),
 	Run: func(cmd *cobra.Command, args []string) {
@@ -97,15 +100,18 @@ You can choose to reset individual keys or all of them at once.
 			fmt.Println(color.CyanString("\n🔑 API Key Reset Menu"))
 			fmt.Println(color.HiBlackString("──────────────────────"))
 			options := map[string]string{
-				"1": "Reset OpenAI API Key 🤖",
-				"2": "Reset Gemini API Key 🧞",
-				"3": "Reset DeepSeek API Key 🔄",
-				"4": "Reset Replicate API Key 🔄",
-				"5": "Reset Ignore List Path 📝",
-				"6": "Reset Ollama URL 🌐",
-				"7": "Reset All Keys ⚠️",
-				"8": "Purge All Genie Data 🗑️",
-				"0": "Exit 👋",
+				"1":  "Reset OpenAI API Key 🤖",
+				"2":  "Reset Gemini API Key 🧞",
+				"3":  "Reset DeepSeek API Key 🔄",
+				"4":  "Reset Replicate API Key 🔄",
+				"5":  "Reset Ignore List Path 📝",
+				"6":  "Reset Ollama URL 🌐",
+				"7":  "Reset OpenAI-compatible URL 🔌",
+				"8":  "Reset OpenAI-compatible API Key 🔌",
+				"9":  "Reset OpenAI-compatible Models 🔌",
+				"10": "Reset All Keys ⚠️",
+				"11": "Purge All Genie Data 🗑️",
+				"0":  "Exit 👋",
 			}
 
 			for num, text := range options {
@@ -180,6 +186,12 @@ You can choose to reset individual keys or all of them at once.
 					prompt = "Enter your DeepSeek API Key:"
 				case ollamaURLKeyName:
 					prompt = "Enter your Ollama URL:"
+				case openAICompatibleURLKeyName:
+					prompt = "Enter the base URL of your OpenAI-compatible server:"
+				case openAICompatibleKeyName:
+					prompt = "Enter the API key for your OpenAI-compatible server:"
+				case openAICompatibleModelsKeyName:
+					prompt = "Enter the comma-separated models served by your OpenAI-compatible server:"
 				default:
 					continue
 				}
@@ -5,12 +5,43 @@ import (
 	"fmt"
 	"html/template"
 	"os"
+	"time"
 
 	"github.com/common-nighthawk/go-figure"
 	"github.com/fatih/color"
+	"github.com/harshalranjhani/genie/internal/config"
+	"github.com/harshalranjhani/genie/internal/helpers/llm"
 	"github.com/spf13/cobra"
 )
 
+var (
+	maxRetries     int
+	maxRetryWait   time.Duration
+	requestTimeout time.Duration
+	engineOverride string
+	modelOverride  string
+	profileFlag    string
+)
+
+func init() {
+	rootCmd.PersistentFlags().IntVar(&maxRetries, "max-retries", llm.DefaultRetryPolicy.MaxRetries, "Number of times a rate-limited or failing engine request is retried")
+	rootCmd.PersistentFlags().DurationVar(&maxRetryWait, "max-retry-wait", llm.DefaultRetryPolicy.MaxDelay, "Longest wait between two retries of an engine request")
+	rootCmd.PersistentFlags().DurationVar(&requestTimeout, "timeout", 0, "Deadline for each engine request, e.g. 30s or 2m (default no deadline)")
+	rootCmd.PersistentFlags().StringVar(&engineOverride, "engine", "", "Engine to use for this command only, overrides GENIE_ENGINE and the stored engine")
+	rootCmd.PersistentFlags().StringVar(&modelOverride, "model", "", "Model to use for this command only, overrides GENIE_MODEL and the stored model")
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "Profile to use for this command only, overrides GENIE_PROFILE and the active profile")
+	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		config.SetProfileOverride(profileFlag)
+		loadProject(cmd)
+		llm.SetRetryPolicy(llm.RetryPolicy{
+			MaxRetries: maxRetries,
+			BaseDelay:  llm.DefaultRetryPolicy.BaseDelay,
+			MaxDelay:   maxRetryWait,
+		})
+		llm.SetUsageCommand(cmd.Name())
+	}
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "genie",
 	Short: "genie is an AI powered CLI tool to help you with your daily tasks.",
@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/harshalranjhani/genie/internal/helpers"
+	"github.com/harshalranjhani/genie/internal/helpers/history"
+	"github.com/harshalranjhani/genie/internal/helpers/llm"
+	"github.com/harshalranjhani/genie/internal/helpers/shell"
+	"github.com/harshalranjhani/genie/pkg/prompts"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// runOutputTail is how much of a failed command's output is sent to the
+// engine.
+const runOutputTail = 8000
+
+// noFix is what the engine answers when no command can fix the failure.
+const noFix = "NONE"
+
+func init() {
+	runCmd.Flags().Bool("last", false, "Run the most recent command of your shell history")
+	runCmd.Flags().Bool("safe", false, "Check the suggested fix against the shell policy")
+	runCmd.Flags().BoolP("yes", "y", false, "Run the suggested fix without asking for confirmation")
+	// Everything after the command name belongs to the command
+	runCmd.Flags().SetInterspersed(false)
+	rootCmd.AddCommand(runCmd)
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run -- <command...>",
+	Short: "Run a command and let the genie diagnose it if it fails",
+	Long: `Run a command with your shell. Its output is shown as usual, and when it fails, the genie explains why and suggests a fixed command you can run after confirmation.
+Example: genie run -- npm run build
+         genie run --last`,
+	Run: func(cmd *cobra.Command, args []string) {
+		env := shell.DetectEnvironment()
+		helpers.SetCommandShell(env.ShellPath)
+
+		last, _ := cmd.Flags().GetBool("last")
+		var command string
+		switch {
+		case last && len(args) > 0:
+			color.Red("--last cannot be combined with a command")
+			os.Exit(1)
+		case last:
+			var err error
+			command, err = shell.LastCommand(env, isGenieCommand)
+			if errors.Is(err, shell.ErrStaleHistory) {
+				color.Red("The last command of your history may be from an earlier session: %v", err)
+				switch env.Shell {
+				case "zsh":
+					color.Yellow("Add 'setopt INC_APPEND_HISTORY' to ~/.zshrc so that zsh writes each command as it runs, or pass the command: genie run -- <command>")
+				default:
+					color.Yellow(`Add 'PROMPT_COMMAND="history -a${PROMPT_COMMAND:+; $PROMPT_COMMAND}"' to ~/.bashrc so that bash writes each command as it runs, or pass the command: genie run -- <command>`)
+				}
+				os.Exit(1)
+			}
+			if err != nil {
+				color.Red("Failed to read your shell history: %v", err)
+				os.Exit(1)
+			}
+			color.Cyan("Running the last command of your history: %s", command)
+		case len(args) == 0:
+			color.Red("Please provide a command to run, e.g. genie run -- make build")
+			os.Exit(1)
+		default:
+			command = shell.JoinArgs(args, env.Shell)
+		}
+
+		output := &tailBuffer{max: runOutputTail}
+		run := exec.Command(env.ShellPath, "-c", command)
+		run.Stdin = os.Stdin
+		run.Stdout = os.Stdout
+		// The command keeps the terminal as its stdout, so that it prints
+		// as it would on its own. Errors are what the diagnosis needs.
+		if !term.IsTerminal(int(os.Stdout.Fd())) {
+			run.Stdout = io.MultiWriter(os.Stdout, output)
+		}
+		run.Stderr = io.MultiWriter(os.Stderr, output)
+		err := run.Run()
+
+		var exitErr *exec.ExitError
+		if err != nil && !errors.As(err, &exitErr) {
+			color.Red("Failed to run the command: %v", err)
+			os.Exit(1)
+		}
+		if err == nil {
+			return
+		}
+		exitCode := exitErr.ExitCode()
+		if exitCode < 0 {
+			// Killed by a signal, e.g. Ctrl+C: nothing to diagnose
+			os.Exit(1)
+		}
+
+		color.Red("\n✗ The command failed with exit code %d.", exitCode)
+		if fixCode, ran := diagnoseFailure(cmd, env, command, exitCode, output.String()); ran {
+			os.Exit(fixCode)
+		}
+		os.Exit(exitCode)
+	},
+}
+
+// diagnoseFailure asks the engine why command failed and offers to run the
+// fix it suggests. It reports the exit code of the fix, if it ran.
+func diagnoseFailure(cmd *cobra.Command, env shell.Environment, command string, exitCode int, output string) (int, bool) {
+	provider, _, err := getProvider()
+	if err != nil {
+		color.Red("Cannot diagnose the failure: %v", err)
+		return 0, false
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		log.Fatal(err)
+	}
+	// The policy can only check a fix written for a shell it can parse
+	safe := safeModeFlag(cmd)
+	fixEnv := env
+	if safe && !shell.Parsable(env.Shell) {
+		color.Yellow("Safe mode cannot check %s commands, so the fix is written for and run with sh instead.", env.Shell)
+		fixEnv = env.WithShell("sh", "safe mode")
+		helpers.SetCommandShell(fixEnv.ShellPath)
+	}
+	environment := fixEnv.Describe()
+	output = helpers.SanitizeUTF8(output)
+
+	var sb strings.Builder
+	if rootDir, err := helpers.GetCurrentDirectoriesAndFiles(dir); err != nil {
+		color.Yellow("Leaving out the directory snapshot: %v", err)
+	} else {
+		budget := contextBudget(provider, prompts.GetRunDiagnosisPrompt(command, exitCode, output, strings.Builder{}, environment))
+		snapshot, notes := helpers.RenderDirectory(rootDir, budget)
+		reportTrimmed(notes)
+		sb.WriteString(snapshot)
+	}
+	prompt := prompts.GetRunDiagnosisPrompt(command, exitCode, output, sb, environment)
+
+	s := newSpinner("Diagnosing: ")
+	ctx, cancel := requestContext()
+	response, err := provider.Complete(ctx, prompt, llm.Options{SafeMode: safe, Caution: prompts.CommandCaution})
+	cancel()
+	s.Stop()
+	if err != nil {
+		exitIfCancelled(err)
+		color.Red("Failed to diagnose the failure: %v", err)
+		return 0, false
+	}
+	reportFallback(provider)
+
+	diagnosis, fix, _ := strings.Cut(response, "\n---")
+	fix = shell.CleanCommand(strings.TrimLeft(fix, "-"))
+	color.Cyan("🩺 Diagnosis:")
+	fmt.Println(indent(strings.TrimSpace(diagnosis)))
+	if fix == "" || strings.EqualFold(fix, noFix) {
+		color.Yellow("The genie has no command that fixes this.")
+		return 0, false
+	}
+
+	var policy *shell.Policy
+	if safe {
+		policy, err = shell.LoadPolicy()
+		if err != nil {
+			color.Red("Failed to load the shell policy: %v", err)
+			return 0, false
+		}
+	}
+
+	record := history.Entry{
+		Prompt:  "fix the failed command: " + command,
+		Engine:  provider.Name(),
+		Model:   provider.Model(),
+		Command: fix,
+		Cwd:     dir,
+	}
+	assumeYes, _ := cmd.Flags().GetBool("yes")
+	fix, ok := confirmCommand(fix, "", fixEnv.Shell, assumeYes, policy)
+	if !ok {
+		recordHistory(record)
+		return 0, false
+	}
+	record.Command = fix
+
+	fmt.Println("Running the command: ", fix)
+	_, fixCode, err := runRecorded(record)
+	if err != nil {
+		color.Red("Failed to run the command: %v", err)
+		return 0, false
+	}
+	return fixCode, true
+}
+
+// isGenieCommand reports whether a history line runs genie itself, which
+// genie run --last skips.
+func isGenieCommand(command string) bool {
+	fields := strings.Fields(command)
+	return len(fields) > 0 && (fields[0] == "genie" || strings.HasSuffix(fields[0], "/genie"))
+}
+
+// tailBuffer keeps the last max bytes written to it.
+type tailBuffer struct {
+	max  int
+	data []byte
+	cut  bool
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.data = append(t.data, p...)
+	if len(t.data) > t.max {
+		t.data = append(t.data[:0], t.data[len(t.data)-t.max:]...)
+		t.cut = true
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	if t.cut {
+		return "[earlier output omitted]\n" + string(t.data)
+	}
+	return string(t.data)
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// keyPattern matches the keys shell-init can bind, e.g. ctrl-g.
+var keyPattern = regexp.MustCompile(`^ctrl-([a-z])$`)
+
+// shellSnippets hold the integration for each shell. %[1]s is the key in the
+// notation of the shell.
+var shellSnippets = map[string]string{
+	"bash": `# genie shell integration for bash. Add to ~/.bashrc:
+#   eval "$(genie shell-init bash)"
+__genie_widget() {
+  [[ -n "$READLINE_LINE" ]] || return
+  local cmd
+  cmd=$(genie do --print -- "$READLINE_LINE") || return
+  READLINE_LINE=$cmd
+  READLINE_POINT=${#READLINE_LINE}
+}
+__genie_tell() {
+  genie tell "$*"
+}
+if [[ $- == *i* ]]; then
+  bind -x '"%[1]s": __genie_widget'
+fi
+alias '??'='__genie_tell'
+`,
+	"zsh": `# genie shell integration for zsh. Add to ~/.zshrc:
+#   eval "$(genie shell-init zsh)"
+__genie_widget() {
+  [[ -n "$BUFFER" ]] || return
+  local cmd
+  zle -I
+  cmd=$(genie do --print -- "$BUFFER") || return
+  BUFFER=$cmd
+  CURSOR=${#BUFFER}
+}
+__genie_tell() {
+  genie tell "$*"
+}
+zle -N __genie_widget
+bindkey '%[1]s' __genie_widget
+alias '??'='noglob __genie_tell'
+`,
+	"fish": `# genie shell integration for fish. Add to ~/.config/fish/config.fish:
+#   genie shell-init fish | source
+function __genie_widget
+    set -l buffer (commandline)
+    test -n "$buffer"; or return
+    set -l cmd (genie do --print -- "$buffer" | string collect); or begin
+        commandline -f repaint
+        return
+    end
+    commandline -r -- $cmd
+    commandline -f repaint
+end
+function __genie_tell
+    genie tell "$argv"
+end
+bind %[1]s __genie_widget
+abbr -a -- '??' __genie_tell
+`,
+}
+
+func init() {
+	shellInitCmd.Flags().String("key", "ctrl-g", "Key that sends the command line to the genie, ctrl-a to ctrl-z")
+	rootCmd.AddCommand(shellInitCmd)
+}
+
+var shellInitCmd = &cobra.Command{
+	Use:       "shell-init <bash|zsh|fish>",
+	Short:     "Print the integration of genie for your shell",
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	Long: `Print a snippet to load in your shell's startup file. It binds Ctrl-G to send what you typed on the command line to genie do, which replaces it with the proposed command for you to review and run, and adds a ?? alias for genie tell.
+Example: eval "$(genie shell-init zsh)"        in ~/.zshrc
+         genie shell-init fish | source        in ~/.config/fish/config.fish`,
+	Run: func(cmd *cobra.Command, args []string) {
+		key, _ := cmd.Flags().GetString("key")
+		snippet, err := shellSnippet(args[0], key)
+		if err != nil {
+			color.Red("%v", err)
+			os.Exit(1)
+		}
+		fmt.Print(snippet)
+	},
+}
+
+// shellSnippet returns the integration for the named shell with the widget
+// bound to key.
+func shellSnippet(shellName, key string) (string, error) {
+	snippet, ok := shellSnippets[shellName]
+	if !ok {
+		return "", fmt.Errorf("unsupported shell %q, use bash, zsh or fish", shellName)
+	}
+	match := keyPattern.FindStringSubmatch(strings.ToLower(key))
+	if match == nil {
+		return "", fmt.Errorf("unsupported key %q, use ctrl-a to ctrl-z", key)
+	}
+
+	var binding string
+	switch shellName {
+	case "bash":
+		binding = `\C-` + match[1]
+	case "zsh":
+		binding = "^" + strings.ToUpper(match[1])
+	case "fish":
+		binding = `\c` + match[1]
+	}
+	return fmt.Sprintf(snippet, binding), nil
+}
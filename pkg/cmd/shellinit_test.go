@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShellSnippet(t *testing.T) {
+	tests := []struct {
+		shell string
+		key   string
+		// want are lines the snippet must contain
+		want []string
+	}{
+		{"bash", "ctrl-g", []string{`bind -x '"\C-g": __genie_widget'`, `alias '??'='__genie_tell'`, `genie do --print -- "$READLINE_LINE"`}},
+		{"bash", "Ctrl-K", []string{`bind -x '"\C-k": __genie_widget'`}},
+		{"zsh", "ctrl-g", []string{`bindkey '^G' __genie_widget`, `alias '??'='noglob __genie_tell'`, `genie do --print -- "$BUFFER"`}},
+		{"zsh", "ctrl-x", []string{`bindkey '^X' __genie_widget`}},
+		{"fish", "ctrl-g", []string{`bind \cg __genie_widget`, `abbr -a -- '??' __genie_tell`, `genie do --print -- "$buffer"`}},
+		{"fish", "ctrl-e", []string{`bind \ce __genie_widget`}},
+	}
+	for _, test := range tests {
+		t.Run(test.shell+" "+test.key, func(t *testing.T) {
+			snippet, err := shellSnippet(test.shell, test.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range test.want {
+				if !strings.Contains(snippet, want) {
+					t.Errorf("snippet does not contain %q:\n%s", want, snippet)
+				}
+			}
+			if strings.Contains(snippet, "%!") {
+				t.Errorf("snippet has a formatting error:\n%s", snippet)
+			}
+
+			// Check the syntax with the shell itself where it is installed
+			path, err := exec.LookPath(test.shell)
+			if err != nil {
+				return
+			}
+			file := filepath.Join(t.TempDir(), "snippet")
+			if err := os.WriteFile(file, []byte(snippet), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if output, err := exec.Command(path, "-n", file).CombinedOutput(); err != nil {
+				t.Errorf("%s rejects the snippet: %v\n%s", test.shell, err, output)
+			}
+		})
+	}
+}
+
+func TestShellSnippetErrors(t *testing.T) {
+	tests := []struct {
+		shell string
+		key   string
+	}{
+		{"powershell", "ctrl-g"},
+		{"bash", "alt-g"},
+		{"zsh", "ctrl-1"},
+		{"fish", "ctrl-gg"},
+		{"bash", ""},
+	}
+	for _, test := range tests {
+		if _, err := shellSnippet(test.shell, test.key); err == nil {
+			t.Errorf("shellSnippet(%q, %q) succeeded, want an error", test.shell, test.key)
+		}
+	}
+}
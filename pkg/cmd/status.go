@@ -4,15 +4,18 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/briandowns/spinner"
 	"github.com/fatih/color"
 	"github.com/harshalranjhani/genie/internal/config"
+	"github.com/harshalranjhani/genie/internal/helpers/llm"
+	"github.com/harshalranjhani/genie/internal/helpers/shell"
 	"github.com/harshalranjhani/genie/internal/middleware"
+	"github.com/harshalranjhani/genie/internal/secrets"
 	"github.com/spf13/cobra"
-	"github.com/zalando/go-keyring"
 )
 
 func init() {
@@ -35,29 +38,35 @@ var statusCmd = &cobra.Command{
 		version := Version
 
 		// Get current engine and its configuration
-		engineName, _ := keyring.Get(serviceName, "engineName")
+		engineValue, _ := currentEngine()
+		engineName := engineValue.Value
 		if engineName == "" {
 			engineName = "Gemini (default)"
 		}
 
 		engine, exists := config.CheckAndGetEngine(engineName)
-		modelName, _ := keyring.Get(serviceName, "modelName")
+		modelValue := currentModel(engineName)
+		modelName := modelValue.Value
 		if modelName == "" && exists {
 			modelName = engine.DefaultModel
 		}
 
 		// Check API keys status
-		openAIKey, _ := keyring.Get(serviceName, openAIKeyName)
-		geminiKey, _ := keyring.Get(serviceName, geminiKeyName)
-		replicateKey, _ := keyring.Get(serviceName, replicateKeyName)
-		deepseekKey, _ := keyring.Get(serviceName, deepseekKeyName)
-		ignoreListPath, _ := keyring.Get(serviceName, ignoreListPathKeyName)
+		openAIKey, _ := secrets.Get(openAIKeyName)
+		geminiKey, _ := secrets.Get(geminiKeyName)
+		replicateKey, _ := secrets.Get(replicateKeyName)
+		deepseekKey, _ := secrets.Get(deepseekKeyName)
+		ignoreList, _ := secrets.Lookup(ignoreListPathKeyName)
+		ignoreListPath := ignoreList.Value
+
+		compatibleURL, _ := secrets.Get(openAICompatibleURLKeyName)
+		compatibleKey, _ := secrets.Get(openAICompatibleKeyName)
 
 		// Add verification status check
 		status, _ := middleware.LoadStatus()
 
 		// Get Ollama URL
-		ollamaURL, _ := keyring.Get(serviceName, ollamaURLKeyName)
+		ollamaURL, _ := secrets.Get(ollamaURLKeyName)
 		if ollamaURL == "" {
 			ollamaURL = "http://localhost:11434 (default)"
 		}
@@ -75,11 +84,28 @@ var statusCmd = &cobra.Command{
 
 		// Version and System Info
 		fmt.Printf("📌 %s: %s\n", color.HiBlackString("Version"), color.HiGreenString(version))
-		fmt.Printf("💻 %s: %s\n", color.HiBlackString("System"), color.HiGreenString(runtime.GOOS))
-		fmt.Printf("⚙️  %s: %s\n", color.HiBlackString("Engine"), color.HiGreenString(engineName))
+		env := shell.DetectEnvironment()
+		system := runtime.GOOS
+		if env.Distro != "" {
+			system += " (" + env.Distro + ")"
+		}
+		fmt.Printf("💻 %s: %s\n", color.HiBlackString("System"), color.HiGreenString(system))
+		fmt.Printf("🐚 %s: %s %s\n", color.HiBlackString("Shell"), color.HiGreenString(env.ShellPath), color.HiBlackString("(from %s)", env.ShellSource))
+		if env.PackageManager != "" {
+			fmt.Printf("📦 %s: %s\n", color.HiBlackString("Package Manager"), color.HiGreenString(env.PackageManager))
+		}
+		if profile, active, err := config.ActiveProfile(); err != nil {
+			fmt.Printf("👤 %s: %s\n", color.HiBlackString("Profile"), color.HiRedString(err.Error()))
+		} else if active {
+			fmt.Printf("👤 %s: %s\n", color.HiBlackString("Profile"), color.HiGreenString(profile.Name))
+		} else {
+			fmt.Printf("👤 %s: %s\n", color.HiBlackString("Profile"), color.HiBlackString("none (global settings)"))
+		}
+		fmt.Printf("⚙️  %s: %s %s\n", color.HiBlackString("Engine"), color.HiGreenString(engineName), sourceLabel(engineValue.Source))
 		if exists {
-			fmt.Printf("🤖 %s: %s\n", color.HiBlackString("Model"), color.HiGreenString(modelName))
+			fmt.Printf("🤖 %s: %s %s\n", color.HiBlackString("Model"), color.HiGreenString(modelName), sourceLabel(modelValue.Source))
 		}
+		printProjectStatus()
 
 		// Features Support
 		if exists {
@@ -108,6 +134,10 @@ var statusCmd = &cobra.Command{
 		fmt.Println("\n🔧 Configuration Status")
 		fmt.Println(strings.Repeat("─", 25))
 
+		fmt.Printf("🗄️  %s: %s\n", color.HiBlackString("Secret Store"), color.HiGreenString(secrets.Backend()))
+		if caveat := secrets.Caveat(); caveat != "" {
+			color.Yellow("   %s", caveat)
+		}
 		printKeyStatus("OpenAI API", openAIKey, revealKeys)
 		printKeyStatus("Gemini API", geminiKey, revealKeys)
 		printKeyStatus("DeepSeek API", deepseekKey, revealKeys)
@@ -117,12 +147,37 @@ var statusCmd = &cobra.Command{
 		color.Green("✓ Configured")
 		fmt.Printf("   %s: %s\n", color.HiBlackString("URL"), color.HiBlackString(ollamaURL))
 
+		fmt.Printf("🔌 %s: ", color.HiBlackString("OpenAI-compatible"))
+		if compatibleURL != "" {
+			color.Green("✓ Configured")
+			fmt.Printf("   %s: %s\n", color.HiBlackString("URL"), color.HiBlackString(compatibleURL))
+			if models := llm.GetOpenAICompatibleModels(); len(models) > 0 {
+				fmt.Printf("   %s: %s\n", color.HiBlackString("Models"), color.HiBlackString(strings.Join(models, ", ")))
+			}
+			if compatibleKey != "" {
+				displayKey := compatibleKey
+				if !revealKeys {
+					displayKey = maskKey(compatibleKey)
+				}
+				fmt.Printf("   %s: %s\n", color.HiBlackString("Key"), color.HiBlackString(displayKey))
+			}
+		} else {
+			color.Yellow("! Not configured")
+		}
+
+		fmt.Printf("↪️  %s: ", color.HiBlackString("Fallback Chain"))
+		if chain := getFallbackChain(engineName); chain != nil {
+			color.Green("✓ %s", strings.Join(chain, " -> "))
+		} else {
+			color.Yellow("! Disabled")
+		}
+
 		// Ignore List Status
 		fmt.Printf("📝 %s: ", color.HiBlackString("Ignore List"))
 		if ignoreListPath != "" {
 			if _, err := os.Stat(ignoreListPath); err == nil {
 				color.Green("✓ Configured")
-				fmt.Printf("   %s: %s\n", color.HiBlackString("Path"), color.HiBlackString(ignoreListPath))
+				fmt.Printf("   %s: %s %s\n", color.HiBlackString("Path"), color.HiBlackString(ignoreListPath), sourceLabel(ignoreList.Source))
 			} else {
 				color.Red("✗ File not found")
 			}
@@ -140,6 +195,57 @@ var statusCmd = &cobra.Command{
 	},
 }
 
+// sourceLabel formats where a setting came from.
+func sourceLabel(source string) string {
+	if source == "" {
+		return ""
+	}
+	return color.HiBlackString("(from %s)", source)
+}
+
+// printProjectStatus shows the project's .genie.yaml and the effective values
+// of the settings it can change.
+func printProjectStatus() {
+	fmt.Println("\n📁 Project Settings")
+	fmt.Println(strings.Repeat("─", 25))
+
+	project := currentProject()
+	if project == nil {
+		fmt.Printf("📄 %s: %s\n", color.HiBlackString("Project File"), color.HiBlackString("none (no %s found)", config.ProjectFileName))
+	} else {
+		fmt.Printf("📄 %s: %s\n", color.HiBlackString("Project File"), color.HiGreenString(project.Path))
+	}
+
+	if project != nil && len(project.Ignore) > 0 {
+		fmt.Printf("📝 %s: %s %s\n", color.HiBlackString("Extra Ignore Patterns"), color.HiGreenString(strings.Join(project.Ignore, ", ")), sourceLabel("project"))
+	}
+
+	safe, safeSource := safeModeDefault()
+	fmt.Printf("🛡️  %s: %s %s\n", color.HiBlackString("Safe Mode"), color.HiGreenString("%t", safe), sourceLabel(safeSource))
+
+	readme := "default"
+	readmeSource := "default"
+	if project != nil && project.ReadmeTemplate != "" {
+		readme, readmeSource = project.ReadmeTemplate, "project"
+	}
+	fmt.Printf("📘 %s: %s %s\n", color.HiBlackString("README Template"), color.HiGreenString(readme), sourceLabel(readmeSource))
+
+	bugDir, bugSource := bugOutputDir()
+	fmt.Printf("🐞 %s: %s %s\n", color.HiBlackString("Bug Reports"), color.HiGreenString(bugDir), sourceLabel(bugSource))
+
+	if project != nil && len(project.PromptAdditions) > 0 {
+		var commands []string
+		for command := range project.PromptAdditions {
+			commands = append(commands, command)
+		}
+		sort.Strings(commands)
+		fmt.Printf("✍️  %s: %s %s\n", color.HiBlackString("Prompt Additions"), color.HiGreenString(strings.Join(commands, ", ")), sourceLabel("project"))
+	}
+	if project != nil && len(project.CommentMarkers) > 0 {
+		fmt.Printf("💬 %s: %s %s\n", color.HiBlackString("Comment Markers"), color.HiGreenString("%d extra", len(project.CommentMarkers)), sourceLabel("project"))
+	}
+}
+
 func printKeyStatus(name string, key string, reveal bool) {
 	fmt.Printf("🔑 %s: ", color.HiBlackString(name))
 	if key != "" {
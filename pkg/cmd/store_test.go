@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"sync"
+
+	"github.com/harshalranjhani/genie/internal/secrets"
+)
+
+// memoryStore is a secret store that lives in memory for the tests.
+type memoryStore struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+// useMemoryStore replaces the secret store with an empty memory store.
+func useMemoryStore(values map[string]string) *memoryStore {
+	if values == nil {
+		values = map[string]string{}
+	}
+	store := &memoryStore{values: values}
+	secrets.SetStore(store)
+	return store
+}
+
+func (m *memoryStore) Name() string { return "memory" }
+
+func (m *memoryStore) Get(key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.values[key]
+	if !ok {
+		return "", secrets.ErrNotFound
+	}
+	return value, nil
+}
+
+func (m *memoryStore) Set(key, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[key] = value
+	return nil
+}
+
+func (m *memoryStore) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.values[key]; !ok {
+		return secrets.ErrNotFound
+	}
+	delete(m.values, key)
+	return nil
+}
@@ -9,9 +9,9 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/harshalranjhani/genie/internal/helpers"
+	"github.com/harshalranjhani/genie/internal/secrets"
 	"github.com/harshalranjhani/genie/internal/structs"
 	"github.com/spf13/cobra"
-	"github.com/zalando/go-keyring"
 )
 
 func init() {
@@ -33,6 +33,12 @@ var summarizeCmd = &cobra.Command{
 		supportFlag, _ := cmd.Flags().GetBool("support")
 		fileName, _ := cmd.Flags().GetString("filename")
 
+		if project := currentProject(); project != nil {
+			for extension, marker := range project.CommentMarkers {
+				commentMarkers[extension] = marker
+			}
+		}
+
 		if supportFlag {
 			color.Yellow("Supported file types for summarization:")
 			for key := range commentMarkers {
@@ -52,7 +58,7 @@ var summarizeCmd = &cobra.Command{
 		c := color.New(color.BgHiBlue).Add(color.Underline)
 		c.Printf("Generating markdown summary for directory: %s\n", root)
 
-		ignoreListPath, err := keyring.Get("genie", "ignore_list_path")
+		ignoreListPath, err := secrets.Get("ignore_list_path")
 		if err != nil {
 			color.Red("Error getting ignore list path: %v", err)
 			return
@@ -64,6 +70,7 @@ var summarizeCmd = &cobra.Command{
 			color.Red("Error reading ignore patterns: %v", err)
 			return
 		}
+		ignorePatterns = append(ignorePatterns, helpers.ProjectIgnorePatterns()...)
 
 		err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 			if err != nil {
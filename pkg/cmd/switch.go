@@ -1,69 +1,30 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
-	"net/http"
 	"strings"
 
 	"github.com/fatih/color"
 	"github.com/harshalranjhani/genie/internal/config"
+	"github.com/harshalranjhani/genie/internal/secrets"
 	"github.com/spf13/cobra"
-	"github.com/zalando/go-keyring"
 )
 
 const (
 	accountName     = "engineName"
 	modelAccountKey = "modelName"
-	ollamaAPIURL    = "http://localhost:11434/api/ps"
 )
 
 var (
-	listModels bool
-	modelName  string
-
-	// Available models for each engine
-	gptModels = []string{
-		"gpt-4",
-		"gpt-4-turbo-preview",
-		"gpt-3.5-turbo",
-		// "o1-preview",
-		// "o1-mini",
-		"gpt-4o",
-		"gpt-4o-2024-11-20",
-		"gpt-4o-2024-08-06",
-		"gpt-4o-mini",
-		"gpt-4o-mini-2024-07-18",
-	}
-
-	geminiModels = []string{
-		"gemini-1.5-pro",
-		"gemini-1.5-flash",
-		"gemini-1.5-flash-8b",
-	}
-
-	// Add DeepSeek models
-	deepseekModels = []string{
-		"deepseek-chat",
-		"deepseek-reasoner",
-	}
+	listModels    bool
+	refreshModels bool
+	modelName     string
 )
 
-type OllamaModel struct {
-	Name    string `json:"name"`
-	Model   string `json:"model"`
-	Details struct {
-		ParameterSize string `json:"parameter_size"`
-	} `json:"details"`
-}
-
-type OllamaResponse struct {
-	Models []OllamaModel `json:"models"`
-}
-
 func init() {
 	switchCmd.Flags().BoolVar(&listModels, "list-models", false, "List available models for the current engine")
 	switchCmd.Flags().StringVar(&modelName, "model", "", "Switch to specified model")
+	switchCmd.Flags().BoolVar(&refreshModels, "refresh", false, "Refresh the cached model list from the provider")
 	rootCmd.AddCommand(switchCmd)
 }
 
@@ -73,27 +34,34 @@ var switchCmd = &cobra.Command{
 	Long:  `This command allows you to switch between different engines (Gemini, GPT, DeepSeek) and their respective models.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Get current engine
-		engineName, err := keyring.Get(serviceName, accountName)
+		engineName, err := secrets.Get(accountName)
 		if err != nil {
 			color.Red("No engine set. Please run `genie init` to set the engine. (default: Gemini)")
 			color.Cyan("Automatically switching to Gemini engine.")
-			err := keyring.Set(serviceName, accountName, "Gemini")
+			err := secrets.Set(accountName, "Gemini")
 			if err != nil {
 				fmt.Println("Something went wrong. Please try again.")
 				return
 			}
 			// Set default model for Gemini
-			err = keyring.Set(serviceName, modelAccountKey, "gemini-1.5-pro")
+			defaultModel := config.EngineMap[config.GeminiEngine].DefaultModel
+			err = secrets.Set(modelAccountKey, defaultModel)
 			if err != nil {
 				fmt.Println("Failed to set default model.")
 				return
 			}
-			color.Green("✓ Switched to Gemini engine with default model (gemini-1.5-pro).")
+			color.Green("✓ Switched to Gemini engine with default model (%s).", defaultModel)
 			return
 		}
 
 		// Handle --list-models flag
 		if listModels {
+			if _, overridden := engineOverrideName(); overridden {
+				if engineName, err = currentEngineName(); err != nil {
+					color.Red("%v", err)
+					return
+				}
+			}
 			printAvailableModels(engineName)
 			return
 		}
@@ -123,7 +91,7 @@ var switchCmd = &cobra.Command{
 			newEngine = config.GetNextEngine(currentEngine.Name)
 		}
 
-		if err := keyring.Set(serviceName, accountName, newEngine); err != nil {
+		if err := secrets.Set(accountName, newEngine); err != nil {
 			color.Red("✗ Something went wrong. Please try again.")
 			return
 		}
@@ -131,7 +99,7 @@ var switchCmd = &cobra.Command{
 		// Set default model for the new engine
 		newEngineConfig, _ := config.CheckAndGetEngine(newEngine)
 		defaultModel := newEngineConfig.DefaultModel
-		if err := keyring.Set(serviceName, modelAccountKey, defaultModel); err != nil {
+		if err := secrets.Set(modelAccountKey, defaultModel); err != nil {
 			color.Red("✗ Failed to set default model.")
 			return
 		}
@@ -140,6 +108,7 @@ var switchCmd = &cobra.Command{
 		color.Cyan("New Engine:")
 		color.Green("  • %s", newEngine)
 		color.Green("  • Default model: %s", defaultModel)
+		warnProjectSelection()
 
 		fmt.Println(strings.Repeat("─", 50))
 		color.HiBlue("Helpful Commands:")
@@ -149,7 +118,7 @@ var switchCmd = &cobra.Command{
 }
 
 func printAvailableModels(engine string) {
-	currentModel, _ := keyring.Get(serviceName, modelAccountKey)
+	currentModel := currentModelName(engine)
 
 	fmt.Println(color.HiMagentaString("📋 Available Models"))
 	fmt.Println(strings.Repeat("─", 50))
@@ -161,32 +130,7 @@ func printAvailableModels(engine string) {
 	color.Green("  • %s", currentModel)
 
 	color.Cyan("\nAvailable Models:")
-	switch engine {
-	case config.GPTEngine:
-		for _, model := range gptModels {
-			if model == currentModel {
-				color.Green("  • %s (current)", model)
-			} else {
-				fmt.Printf("  • %s\n", model)
-			}
-		}
-	case config.GeminiEngine:
-		for _, model := range geminiModels {
-			if model == currentModel {
-				color.Green("  • %s (current)", model)
-			} else {
-				fmt.Printf("  • %s\n", model)
-			}
-		}
-	case config.DeepSeekEngine:
-		for _, model := range deepseekModels {
-			if model == currentModel {
-				color.Green("  • %s (current)", model)
-			} else {
-				fmt.Printf("  • %s\n", model)
-			}
-		}
-	}
+	printModelList(availableModels(engine, refreshModels), currentModel)
 
 	fmt.Println(strings.Repeat("─", 50))
 	color.HiBlue("Helpful Commands:")
@@ -194,46 +138,11 @@ func printAvailableModels(engine string) {
 	fmt.Println("• Change model: genie switch --model <model-name>")
 }
 
-func getRunningOllamaModels() ([]string, error) {
-	resp, err := http.Get(ollamaAPIURL)
-	if err != nil {
-		return nil, fmt.Errorf("Ollama server not running. Please ensure your Ollama server is running")
-	}
-	defer resp.Body.Close()
-
-	var ollamaResp OllamaResponse
-	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
-		return nil, fmt.Errorf("Failed to parse Ollama response: %v", err)
-	}
-
-	var models []string
-	for _, model := range ollamaResp.Models {
-		models = append(models, model.Name)
-	}
-	return models, nil
-}
-
 func switchModel(engine, model string) error {
-	var validModels []string
-
-	switch engine {
-	case config.OllamaEngine:
-		models, err := getRunningOllamaModels()
-		if err != nil {
-			color.Red("\nError: %v", err)
-			return nil
-		}
-		if len(models) == 0 {
-			color.Yellow("\nNo running Ollama models found. Please start some models first.")
-			return nil
-		}
-		validModels = models
-	case config.GPTEngine:
-		validModels = gptModels
-	case config.GeminiEngine:
-		validModels = geminiModels
-	case config.DeepSeekEngine:
-		validModels = deepseekModels
+	validModels := availableModels(engine, refreshModels)
+	if len(validModels) == 0 {
+		color.Yellow("\nNo models found for %s. Use --list-models --refresh to check the provider.", engine)
+		return nil
 	}
 
 	// If listing models is requested
@@ -247,27 +156,21 @@ func switchModel(engine, model string) error {
 	}
 
 	// Validate model name
-	isValid := false
-	for _, validModel := range validModels {
-		if strings.EqualFold(validModel, model) {
-			isValid = true
-			model = validModel // Use the correct case from the valid models list
-			break
-		}
-	}
-
+	correctModel, isValid := matchModel(validModels, model)
 	if !isValid {
 		return fmt.Errorf("Invalid model name for %s engine. Use --list-models to see available models", engine)
 	}
 
-	currentModel, _ := keyring.Get(serviceName, modelAccountKey)
+	currentModel, _ := secrets.Get(modelAccountKey)
 	color.Cyan("\nCurrent Model:")
 	color.Green("  • %s", currentModel)
 
-	if err := keyring.Set(serviceName, modelAccountKey, model); err != nil {
+	model = correctModel
+	if err := secrets.Set(modelAccountKey, model); err != nil {
 		return fmt.Errorf("Failed to switch model: %v", err)
 	}
 
+	warnProjectSelection()
 	color.Cyan("\nNew Model:")
 	color.Green("  • %s", model)
 
@@ -1,31 +1,32 @@
 package cmd
 
 import (
-	"fmt"
 	"log"
 	"os"
-	"regexp"
 	"strings"
 
 	"github.com/fatih/color"
-	"github.com/harshalranjhani/genie/internal/config"
 	"github.com/harshalranjhani/genie/internal/helpers"
 	"github.com/harshalranjhani/genie/internal/helpers/llm"
+	"github.com/harshalranjhani/genie/internal/helpers/usage"
 	"github.com/harshalranjhani/genie/pkg/prompts"
 	"github.com/spf13/cobra"
-	"github.com/zalando/go-keyring"
 )
 
 func init() {
 	rootCmd.AddCommand(tellCmd)
 	tellCmd.PersistentFlags().Bool("include-dir", false, "Option to include the current directory snapshot in the request.")
 	tellCmd.PersistentFlags().Bool("include-git-changes", false, "Option to include git repository information in the request.")
+	addFileFlag(tellCmd)
 }
 
 var tellCmd = &cobra.Command{
 	Use:   "tell",
 	Short: "This is a command to seek help from the genie",
-	Long:  `This is a command to seek help from the genie. For example: 'genie tell "what is docker?"'`,
+	Long: `This is a command to seek help from the genie. For example: 'genie tell "what is docker?"'
+Pipe a log into it or attach files to ask about them:
+  kubectl logs x | genie tell "why is this crashing"
+  genie tell "what does this function do" --file main.go:10-80`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) < 1 {
 			color.Red("Please provide a question for the genie")
@@ -33,15 +34,11 @@ var tellCmd = &cobra.Command{
 		}
 
 		prompt := args[0]
-		engineName, err := keyring.Get(serviceName, "engineName")
+		provider, _, err := getProvider()
 		if err != nil {
-			log.Fatal("Error retrieving engine name from keyring:", err)
-		}
-
-		_, exists := config.CheckAndGetEngine(engineName)
-		if !exists {
-			log.Fatal("Unknown engine name: ", engineName)
+			log.Fatal(err)
 		}
+		attachments := readAttachments(cmd, contextBudget(provider, prompts.GetTellPrompt(prompt, strings.Builder{}, nil)))
 
 		includeDir, _ := cmd.Flags().GetBool("include-dir")
 		includeGit, _ := cmd.Flags().GetBool("include-git-changes")
@@ -54,17 +51,28 @@ var tellCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
+		budget := contextBudget(provider, prompts.GetTellPrompt(prompt, strings.Builder{}, attachments))
+
 		if includeDir {
 			rootDir, err := helpers.GetCurrentDirectoriesAndFiles(dir)
 			if err != nil {
 				log.Fatal(err)
 				os.Exit(1)
 			}
-			helpers.PrintData(&sb, rootDir, 0)
+
+			// Leave at least half of the budget to the git changes
+			dirBudget := budget
+			if includeGit {
+				dirBudget = budget / 2
+			}
+			snapshot, notes := helpers.RenderDirectory(rootDir, dirBudget)
+			reportTrimmed(notes)
+			sb.WriteString(snapshot)
 		}
 
 		if includeGit {
-			gitInfo, err := helpers.GetGitInfo(dir)
+			gitInfo, notes, err := helpers.GetGitInfo(dir, budget-usage.EstimateTokens(sb.String()))
+			reportTrimmed(notes)
 			if err != nil {
 				color.Red("Warning: Could not get git information: %v", err)
 			} else {
@@ -73,50 +81,23 @@ var tellCmd = &cobra.Command{
 			}
 		}
 
-		prompt = prompts.GetTellPrompt(prompt, sb)
+		prompt = prompts.GetTellPrompt(prompt, sb, attachments)
 
-		switch engineName {
-		case config.GPTEngine:
-			llm.GetGPTGeneralResponse(prompt, includeDir)
-		case config.GeminiEngine:
-			strResp, err := llm.GetGeminiGeneralResponse(prompt, true, includeDir)
-			if err != nil {
-				log.Fatal("Error getting response from Gemini: ", err)
-				os.Exit(1)
-			}
-			fmt.Println(formatMarkdownToPlainText(strResp))
-		case config.DeepSeekEngine:
-			err := llm.GetDeepSeekGeneralResponse(prompt, true, includeDir)
-			if err != nil {
-				log.Fatal("Error getting response from DeepSeek: ", err)
-				os.Exit(1)
-			}
-		case config.OllamaEngine:
-			model, err := keyring.Get(serviceName, modelAccountKey)
-			if err != nil {
-				log.Fatal("Error retrieving model name from keyring: ", err)
-			}
-			err = llm.GetOllamaGeneralResponse(prompt, model, includeDir)
-			if err != nil {
-				log.Fatal("Error getting response from Ollama: ", err)
-				os.Exit(1)
-			}
-		}
-	},
-}
-
-func formatMarkdownToPlainText(mdText string) string {
-	// Regular expressions to replace Markdown formatting
-	reStrong := regexp.MustCompile(`\*\*(.*?)\*\*`)
-	reEmphasis := regexp.MustCompile(`\*(.*?)\*`)
-	reCode := regexp.MustCompile("([^])" + "`" + "(.*?)" + "`" + "([^`])")
-	reHeaders := regexp.MustCompile(`\n#+\s(.*?)\n`)
+		s := newSpinner("Analyzing: ")
+		ctx, cancel := requestContext()
+		defer cancel()
 
-	// Replace Markdown syntax with plain text formatting
-	plainText := reStrong.ReplaceAllString(mdText, "$1")
-	plainText = reEmphasis.ReplaceAllString(plainText, "$1")
-	plainText = reCode.ReplaceAllString(plainText, "$1")
-	plainText = reHeaders.ReplaceAllString(plainText, "\n$1\n")
+		stream, err := provider.Stream(ctx, prompt, llm.Options{SafeMode: true})
+		s.Stop()
+		if err != nil {
+			exitIfCancelled(err)
+			log.Fatalf("Error getting response from %s: %v", provider.Name(), err)
+		}
+		reportFallback(provider)
 
-	return plainText
+		if _, err := printStream(stream); err != nil {
+			exitIfCancelled(err)
+			log.Fatalf("Error getting response from %s: %v", provider.Name(), err)
+		}
+	},
 }
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/harshalranjhani/genie/internal/helpers/llm"
+	"github.com/harshalranjhani/genie/internal/helpers/usage"
+	"github.com/harshalranjhani/genie/internal/secrets"
+	"github.com/spf13/cobra"
+)
+
+const (
+	budgetWarn   = "warn"
+	budgetRefuse = "refuse"
+)
+
+var (
+	usageDays        int
+	usageExport      string
+	usageOutput      string
+	usageSetBudget   float64
+	usageBudgetMode  string
+	usageClearBudget bool
+)
+
+func init() {
+	usageCmd.Flags().IntVar(&usageDays, "days", 30, "Number of days to include")
+	usageCmd.Flags().StringVar(&usageExport, "export", "", "Export the ledger entries as csv or json")
+	usageCmd.Flags().StringVar(&usageOutput, "output", "", "File to write the export to (default stdout)")
+	usageCmd.Flags().Float64Var(&usageSetBudget, "set-budget", 0, "Set a monthly budget in USD")
+	usageCmd.Flags().StringVar(&usageBudgetMode, "budget-mode", "", "What to do once the budget is spent: warn or refuse")
+	usageCmd.Flags().BoolVar(&usageClearBudget, "clear-budget", false, "Remove the monthly budget")
+	rootCmd.AddCommand(usageCmd)
+}
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show token usage and cost of your engine requests",
+	Long: `Every engine request is recorded in ~/.genie/usage.jsonl with its token counts and cost.
+This command summarizes the ledger per day, model and command, exports it and manages the monthly budget.
+Failed requests and every retry are recorded too, under the engine that served them.
+Costs are computed from list prices; token counts marked with ~ are estimated.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if usageClearBudget {
+			_ = secrets.Delete(monthlyBudgetKeyName)
+			_ = secrets.Delete(budgetModeKeyName)
+			color.Green("✓ Monthly budget removed.")
+			return
+		}
+
+		if cmd.Flags().Changed("set-budget") || usageBudgetMode != "" {
+			if err := storeBudget(cmd.Flags().Changed("set-budget")); err != nil {
+				color.Red("✗ %v", err)
+			}
+			return
+		}
+
+		since := time.Now().AddDate(0, 0, -usageDays)
+		entries, err := usage.Load(since)
+		if err != nil {
+			color.Red("Failed to read the usage ledger: %v", err)
+			return
+		}
+
+		if usageExport != "" {
+			if err := exportUsage(entries); err != nil {
+				color.Red("Failed to export usage: %v", err)
+			}
+			return
+		}
+
+		printUsage(entries)
+	},
+}
+
+func storeBudget(setLimit bool) error {
+	if setLimit {
+		if usageSetBudget <= 0 {
+			return fmt.Errorf("the budget must be a positive amount in USD")
+		}
+		if err := secrets.Set(monthlyBudgetKeyName, strconv.FormatFloat(usageSetBudget, 'f', 2, 64)); err != nil {
+			return fmt.Errorf("failed to store the budget: %w", err)
+		}
+	}
+
+	if usageBudgetMode != "" {
+		mode := strings.ToLower(usageBudgetMode)
+		if mode != budgetWarn && mode != budgetRefuse {
+			return fmt.Errorf("invalid budget mode %q, use warn or refuse", usageBudgetMode)
+		}
+		if err := secrets.Set(budgetModeKeyName, mode); err != nil {
+			return fmt.Errorf("failed to store the budget mode: %w", err)
+		}
+	}
+
+	budget := getBudget()
+	color.Green("✓ Monthly budget set to $%.2f (%s).", budget.Limit, budgetModeName(budget))
+	return nil
+}
+
+// getBudget reads the monthly budget from the secret store. Without a stored mode
+// the budget only warns.
+func getBudget() usage.Budget {
+	limit, err := secrets.Get(monthlyBudgetKeyName)
+	if err != nil {
+		return usage.Budget{}
+	}
+
+	value, err := strconv.ParseFloat(limit, 64)
+	if err != nil {
+		return usage.Budget{}
+	}
+
+	mode, _ := secrets.Get(budgetModeKeyName)
+	return usage.Budget{Limit: value, Refuse: mode == budgetRefuse}
+}
+
+func budgetModeName(budget usage.Budget) string {
+	if budget.Refuse {
+		return budgetRefuse
+	}
+	return budgetWarn
+}
+
+// checkBudget applies the monthly budget to engine requests and warns when
+// it has been spent.
+func checkBudget() {
+	budget := getBudget()
+	llm.SetBudget(budget)
+
+	spent, err := budget.Check()
+	if err == nil && budget.Limit > 0 && spent >= budget.Limit {
+		color.Yellow("⚠️  Monthly budget exceeded: spent $%.2f of $%.2f.", spent, budget.Limit)
+	}
+}
+
+func exportUsage(entries []usage.Entry) error {
+	out := os.Stdout
+	if usageOutput != "" {
+		f, err := os.Create(usageOutput)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	switch strings.ToLower(usageExport) {
+	case "csv":
+		return usage.WriteCSV(out, entries)
+	case "json":
+		return usage.WriteJSON(out, entries)
+	default:
+		return fmt.Errorf("unknown export format %q, use csv or json", usageExport)
+	}
+}
+
+func printUsage(entries []usage.Entry) {
+	fmt.Println(color.HiMagentaString("📊 Usage (last %d days)", usageDays))
+	fmt.Println(strings.Repeat("─", 50))
+
+	if len(entries) == 0 {
+		color.Yellow("No requests recorded yet.")
+		return
+	}
+
+	for _, total := range usage.Summarize(entries, usage.Total) {
+		color.Cyan("Total:")
+		fmt.Printf("  %s, %s tokens, %s\n", formatRequests(total), formatTokens(total), color.GreenString("$%.4f", total.Cost))
+	}
+
+	if budget := getBudget(); budget.Limit > 0 {
+		spent, _ := usage.MonthToDate()
+		color.Cyan("\nThis Month:")
+		line := fmt.Sprintf("  $%.2f of $%.2f budget (%s)", spent, budget.Limit, budgetModeName(budget))
+		if spent >= budget.Limit {
+			color.Red(line)
+		} else {
+			fmt.Println(line)
+		}
+	}
+
+	printUsageRows("By Day", usage.Summarize(entries, usage.ByDay))
+	printUsageRows("By Model", usage.Summarize(entries, usage.ByModel))
+	printUsageRows("By Command", usage.Summarize(entries, usage.ByCommand))
+
+	fmt.Println(strings.Repeat("─", 50))
+	color.HiBlue("Helpful Commands:")
+	fmt.Println("• Export:      genie usage --export csv --output usage.csv")
+	fmt.Println("• Set budget:  genie usage --set-budget 20 --budget-mode refuse")
+}
+
+func printUsageRows(title string, rows []usage.Row) {
+	color.Cyan("\n%s:", title)
+	for _, row := range rows {
+		fmt.Printf("  %-32s %-24s %12s tokens  $%.4f\n", row.Key, formatRequests(row), formatTokens(row), row.Cost)
+	}
+}
+
+// formatRequests prints the requests of a row and how many of them failed.
+func formatRequests(row usage.Row) string {
+	if row.Failed == 0 {
+		return fmt.Sprintf("%d requests", row.Requests)
+	}
+	return fmt.Sprintf("%d requests (%d failed)", row.Requests, row.Failed)
+}
+
+// formatTokens prints the total tokens of a row, marking estimated counts.
+func formatTokens(row usage.Row) string {
+	tokens := strconv.Itoa(row.TotalTokens())
+	if row.Estimated {
+		return "~" + tokens
+	}
+	return tokens
+}
@@ -6,8 +6,8 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/harshalranjhani/genie/internal/config"
+	"github.com/harshalranjhani/genie/internal/secrets"
 	"github.com/spf13/cobra"
-	"github.com/zalando/go-keyring"
 )
 
 var (
@@ -16,7 +16,7 @@ var (
 )
 
 func init() {
-	useCmd.Flags().StringVar(&engineFlag, "engine", "", "Specify the engine (GPT, Gemini, DeepSeek, Ollama)")
+	useCmd.Flags().StringVar(&engineFlag, "engine", "", "Specify the engine (GPT, Gemini, DeepSeek, Ollama, OpenAICompatible)")
 	useCmd.Flags().StringVar(&modelFlag, "model", "", "Specify the model to use")
 	useCmd.MarkFlagRequired("engine")
 	useCmd.MarkFlagRequired("model")
@@ -28,43 +28,22 @@ var useCmd = &cobra.Command{
 	Short: "Directly switch to a specific engine and model combination",
 	Long:  `Switch to a specific engine and model combination in one command. Example: genie use --engine GPT --model gpt-4`,
 	Run: func(cmd *cobra.Command, args []string) {
-		// Normalize engine name to match config
-		engineName := strings.Title(strings.ToLower(engineFlag))
-
 		// Validate engine
-		engine, exists := config.CheckAndGetEngine(engineName)
+		engine, exists := config.CheckAndGetEngine(engineFlag)
 		if !exists {
-			color.Red("Invalid engine. Available engines: GPT, Gemini, DeepSeek, Ollama")
+			color.Red("Invalid engine. Available engines: GPT, Gemini, DeepSeek, Ollama, OpenAICompatible")
 			return
 		}
+		engineName := engine.Name
 
-		// Validate model based on engine
-		var validModels []string
-		if engineName == config.OllamaEngine {
-			models, err := getRunningOllamaModels()
-			if err != nil {
-				color.Red("Error: %v", err)
-				return
-			}
-			if len(models) == 0 {
-				color.Yellow("No running Ollama models found. Please start some models first.")
-				return
-			}
-			validModels = models
-		} else {
-			validModels = engine.Models
-		}
-
-		isValidModel := false
-		var correctModelName string
-		for _, validModel := range validModels {
-			if strings.EqualFold(validModel, modelFlag) {
-				isValidModel = true
-				correctModelName = validModel
-				break
-			}
+		// Validate model against the models the engine currently serves
+		validModels := availableModels(engineName, false)
+		if len(validModels) == 0 {
+			color.Yellow("No models found for the %s engine.", engineName)
+			return
 		}
 
+		correctModelName, isValidModel := matchModel(validModels, modelFlag)
 		if !isValidModel {
 			color.Red("Invalid model for %s engine. Available models:", engineName)
 			for _, model := range validModels {
@@ -74,12 +53,12 @@ var useCmd = &cobra.Command{
 		}
 
 		// Set engine and model
-		if err := keyring.Set(serviceName, accountName, engineName); err != nil {
+		if err := secrets.Set(accountName, engineName); err != nil {
 			color.Red("Failed to set engine: %v", err)
 			return
 		}
 
-		if err := keyring.Set(serviceName, modelAccountKey, correctModelName); err != nil {
+		if err := secrets.Set(modelAccountKey, correctModelName); err != nil {
 			color.Red("Failed to set model: %v", err)
 			return
 		}
@@ -91,5 +70,6 @@ var useCmd = &cobra.Command{
 		color.Green("  • %s", engine.Name)
 		color.Cyan("\nModel:")
 		color.Green("  • %s", correctModelName)
+		warnProjectSelection()
 	},
 }